@@ -0,0 +1,211 @@
+package apirest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RegistroDeAcceso es la información de una solicitud procesada, registrada
+// por InterceptorRegistroDeAccesos.
+type RegistroDeAcceso struct {
+	Fecha           time.Time         `json:"fecha"`                     // fecha y hora de recepción de la solicitud
+	Metodo          string            `json:"metodo"`                    // método HTTP
+	URI             string            `json:"uri"`                       // ruta y consulta de la solicitud
+	Patron          string            `json:"patron,omitempty"`          // patrón de ruta del endpoint ("/personas/{id:int}")
+	Variables       map[string]string `json:"variables,omitempty"`       // variables del patrón de ruta
+	Protocolo       string            `json:"protocolo"`                 // versión del protocolo ("HTTP/1.1")
+	Estado          int               `json:"estado"`                    // código de estado HTTP respondido
+	Bytes           int               `json:"bytes"`                     // cantidad de bytes del cuerpo respondido
+	Duracion        time.Duration     `json:"duracion"`                  // tiempo de procesamiento (en nanosegundos)
+	IPRemota        string            `json:"ipRemota"`                  // dirección IP del cliente
+	Usuario         string            `json:"usuario,omitempty"`         // usuario de la autenticación básica
+	IDDeSolicitud   string            `json:"idDeSolicitud,omitempty"`   // identificador de la solicitud (ver IdentificadorDeSolicitud)
+	Referente       string            `json:"referente,omitempty"`       // campo de cabecera "Referer"
+	AgenteDeUsuario string            `json:"agenteDeUsuario,omitempty"` // campo de cabecera "User-Agent"
+}
+
+// RegistradorDeAccesosFunc es el tipo (función) que registra los accesos
+// (ver InterceptorRegistroDeAccesos).
+type RegistradorDeAccesosFunc func(registro RegistroDeAcceso)
+
+// RegistradorEstructurado es la interface de los registradores que reciben un
+// mensaje y pares clave/valor, como *slog.Logger.
+type RegistradorEstructurado interface {
+	Info(mensaje string, args ...interface{})
+}
+
+// datosDeAcceso almacena, en el contexto de la solicitud, los datos que
+// obtiene el enrutador al buscar el patrón de ruta y las funciones que se
+// ejecutan una vez respondida la solicitud.
+type datosDeAcceso struct {
+	patron      string
+	variables   map[string]string
+	alFinalizar []func(estado, bytes int)
+}
+
+// finalizar ejecuta las funciones registradas con el código de estado y la
+// cantidad de bytes respondidos.
+func (o *datosDeAcceso) finalizar(w *escritorDeRespuesta) {
+	if len(o.alFinalizar) == 0 {
+		return
+	}
+
+	estado := estadoRespondido(w)
+	for _, funcion := range o.alFinalizar {
+		funcion(estado, w.longitud)
+	}
+}
+
+// estadoRespondido devuelve el código de estado con el que se respondió la
+// solicitud, una vez finalizada.
+func estadoRespondido(w *escritorDeRespuesta) int {
+	switch {
+	case !w.escrito:
+		// la respuesta se abortó (http.ErrAbortHandler)
+		return HTTPEstadoErrorInternoDeServidor.obtenerEntero()
+	case w.estado == 0:
+		// la respuesta se envió sin código de estado (Flush o Hijack)
+		return HTTPEstadoOk.obtenerEntero()
+	}
+
+	return w.estado
+}
+
+// InterceptorRegistroDeAccesos devuelve un interceptor (middleware) que
+// registra cada solicitud: método, patrón de ruta, variables, código de
+// estado, bytes respondidos, duración, IP remota e identificador de la
+// solicitud. Si el registrador es nil, se registra en formato JSON (una línea
+// por solicitud) en la salida estándar.
+// El acceso se registra una vez que el enrutador respondió la solicitud
+// (incluidos los errores y los pánicos, registrados con su código de estado),
+// por lo que el interceptor no modifica los valores devueltos por la función
+// (ManejadorFunc) y puede ubicarse en cualquier posición de la cadena.
+// Ejemplo:
+// 	r.Usar(apirest.InterceptorRegistroDeAccesos(apirest.RegistradorDeAccesosApache(os.Stdout)))
+func InterceptorRegistroDeAccesos(registrar RegistradorDeAccesosFunc) InterceptorFunc {
+	if registrar == nil {
+		registrar = RegistradorDeAccesosJSON(os.Stdout)
+	}
+
+	return func(manejadorFunc ManejadorFunc) ManejadorFunc {
+		return func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+			inicio := time.Now()
+			if acceso, ok := r.Context().Value(claveAcceso).(*datosDeAcceso); ok {
+				acceso.alFinalizar = append(acceso.alFinalizar, func(estado, bytes int) {
+					registrar(nuevoRegistroDeAcceso(r, inicio, acceso, estado, bytes))
+				})
+			}
+
+			return manejadorFunc(w, r)
+		}
+	}
+}
+
+// nuevoRegistroDeAcceso crea el registro de acceso de la solicitud.
+func nuevoRegistroDeAcceso(r *http.Request, inicio time.Time, acceso *datosDeAcceso, estado, bytes int) RegistroDeAcceso {
+	usuario, _, _ := r.BasicAuth()
+
+	return RegistroDeAcceso{
+		Fecha:           inicio,
+		Metodo:          r.Method,
+		URI:             r.URL.RequestURI(),
+		Patron:          acceso.patron,
+		Variables:       acceso.variables,
+		Protocolo:       r.Proto,
+		Estado:          estado,
+		Bytes:           bytes,
+		Duracion:        time.Since(inicio),
+		IPRemota:        obtenerIPRemota(r),
+		Usuario:         usuario,
+		IDDeSolicitud:   ObtenerIDDeSolicitud(r),
+		Referente:       r.Referer(),
+		AgenteDeUsuario: r.UserAgent(),
+	}
+}
+
+// obtenerIPRemota devuelve la dirección IP del cliente (sin el puerto).
+func obtenerIPRemota(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return ip
+}
+
+// -----------------------------------------------------------------------------
+// Registradores de accesos.
+
+// RegistradorDeAccesosJSON registra los accesos en formato JSON, una línea por
+// solicitud.
+func RegistradorDeAccesosJSON(w io.Writer) RegistradorDeAccesosFunc {
+	var mutex sync.Mutex
+	return func(registro RegistroDeAcceso) {
+		linea, err := json.Marshal(registro)
+		if err != nil {
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		w.Write(append(linea, '\n'))
+	}
+}
+
+// RegistradorDeAccesosApache registra los accesos en el formato combinado de
+// Apache (Combined Log Format):
+// 	127.0.0.1 - ana [10/Oct/2021:13:55:36 -0300] "GET /personas/5 HTTP/1.1" 200 2326 "http://ejemplo.com/" "Mozilla/5.0"
+func RegistradorDeAccesosApache(w io.Writer) RegistradorDeAccesosFunc {
+	var mutex sync.Mutex
+	return func(registro RegistroDeAcceso) {
+		bytes := "-"
+		if registro.Bytes > 0 {
+			bytes = fmt.Sprint(registro.Bytes)
+		}
+
+		linea := fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %s \"%s\" \"%s\"\n",
+			registro.IPRemota, valorOGuion(registro.Usuario), registro.Fecha.Format("02/Jan/2006:15:04:05 -0700"),
+			registro.Metodo, registro.URI, registro.Protocolo, registro.Estado, bytes,
+			valorOGuion(registro.Referente), valorOGuion(registro.AgenteDeUsuario))
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		io.WriteString(w, linea)
+	}
+}
+
+// RegistradorDeAccesosEstructurado registra los accesos a través de un
+// registrador estructurado (por ejemplo, *slog.Logger), con el mensaje
+// "acceso" y los datos del registro como pares clave/valor.
+func RegistradorDeAccesosEstructurado(registrador RegistradorEstructurado) RegistradorDeAccesosFunc {
+	return func(registro RegistroDeAcceso) {
+		registrador.Info("acceso",
+			"metodo", registro.Metodo,
+			"uri", registro.URI,
+			"patron", registro.Patron,
+			"variables", registro.Variables,
+			"estado", registro.Estado,
+			"bytes", registro.Bytes,
+			"duracion", registro.Duracion,
+			"ipRemota", registro.IPRemota,
+			"idDeSolicitud", registro.IDDeSolicitud,
+		)
+	}
+}
+
+// valorOGuion devuelve el valor recibido o "-" si es vacío (formato Apache).
+// Las comillas se escapan para no romper el formato de la línea.
+func valorOGuion(valor string) string {
+	if valor == "" {
+		return "-"
+	}
+
+	return strings.ReplaceAll(valor, `"`, `\"`)
+}
@@ -0,0 +1,180 @@
+package apirest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInterceptorRegistroDeAccesos(t *testing.T) {
+	var registros []RegistroDeAcceso
+	r := CrearEnrutador().IdentificadorDeSolicitud("")
+	r.Usar(InterceptorRegistroDeAccesos(func(registro RegistroDeAcceso) {
+		registros = append(registros, registro)
+	}))
+	r.GET("/personas/{id:int}", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return map[string]string{"id": ObtenerVariablesDeRuta(r)["id"]}, nil
+	})
+	r.DELETE("/personas/{id:int}", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, ErrorNuevoSinPrivilegios("Sin privilegios")
+	})
+	r.GET("/panico", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		panic("falla")
+	})
+
+	pruebas := []struct {
+		metodo string
+		ruta   string
+		patron string
+		estado int
+	}{
+		{"GET", "/personas/5?campos=nombre", "/personas/{id:int}", 200},
+		{"DELETE", "/personas/5", "/personas/{id:int}", 403},
+		{"GET", "/panico", "/panico", 500},
+		{"GET", "/inexistente", "", 404},
+		{"PUT", "/personas/5", "/personas/{id:int}", 405},
+	}
+	for _, p := range pruebas {
+		registros = nil
+		w := solicitar(r, p.metodo, p.ruta, map[string]string{"X-Request-ID": "abc", "User-Agent": "prueba"})
+		if len(registros) != 1 {
+			t.Fatalf("%s %s: se obtuvieron %d registros", p.metodo, p.ruta, len(registros))
+		}
+
+		registro := registros[0]
+		if registro.Metodo != p.metodo || registro.URI != p.ruta || registro.Patron != p.patron ||
+			registro.Estado != p.estado || registro.Estado != w.Code || registro.Bytes != w.Body.Len() ||
+			registro.IDDeSolicitud != "abc" || registro.AgenteDeUsuario != "prueba" || registro.Protocolo != "HTTP/1.1" ||
+			registro.IPRemota != "192.0.2.1" || registro.Fecha.IsZero() || registro.Duracion <= 0 {
+			t.Errorf("%s %s: se obtuvo %+v", p.metodo, p.ruta, registro)
+		}
+		if p.patron == "/personas/{id:int}" && !reflect.DeepEqual(registro.Variables, map[string]string{"id": "5"}) {
+			t.Errorf("%s %s: se obtuvieron las variables %v", p.metodo, p.ruta, registro.Variables)
+		}
+	}
+}
+
+func TestInterceptorRegistroDeAccesosNoModificaElResultado(t *testing.T) {
+	errEsperado := ErrorNuevoConflicto("Ya existe")
+	var valorRecibido interface{}
+	var errRecibido error
+	var estadoRegistrado int
+
+	r := CrearEnrutador()
+	// el interceptor exterior recibe los valores devueltos por la función
+	r.Usar(func(manejadorFunc ManejadorFunc) ManejadorFunc {
+		return func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+			valorRecibido, errRecibido = manejadorFunc(w, r)
+			return valorRecibido, errRecibido
+		}
+	})
+	r.Usar(InterceptorRegistroDeAccesos(func(registro RegistroDeAcceso) {
+		estadoRegistrado = registro.Estado
+	}))
+	r.POST("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return "valor", errEsperado
+	})
+
+	w := solicitar(r, "POST", "/personas", nil)
+	if valorRecibido != "valor" || errRecibido != errEsperado {
+		t.Errorf("el interceptor exterior recibió %v %v", valorRecibido, errRecibido)
+	}
+	if w.Code != http.StatusConflict || estadoRegistrado != http.StatusConflict {
+		t.Errorf("se obtuvo %d, se registró %d", w.Code, estadoRegistrado)
+	}
+}
+
+func TestRegistradorDeAccesosJSON(t *testing.T) {
+	var salida bytes.Buffer
+	registrar := RegistradorDeAccesosJSON(&salida)
+	registro := RegistroDeAcceso{
+		Fecha:     time.Date(2021, 10, 10, 13, 55, 36, 0, time.UTC),
+		Metodo:    "GET",
+		URI:       "/personas/5",
+		Patron:    "/personas/{id}",
+		Variables: map[string]string{"id": "5"},
+		Protocolo: "HTTP/1.1",
+		Estado:    200,
+		Bytes:     12,
+		Duracion:  1500,
+		IPRemota:  "127.0.0.1",
+	}
+	registrar(registro)
+	registrar(registro)
+
+	lineas := strings.Split(strings.TrimSuffix(salida.String(), "\n"), "\n")
+	if len(lineas) != 2 {
+		t.Fatalf("se obtuvieron %d líneas: %q", len(lineas), salida.String())
+	}
+	var decodificado RegistroDeAcceso
+	if err := json.Unmarshal([]byte(lineas[0]), &decodificado); err != nil || !reflect.DeepEqual(decodificado, registro) {
+		t.Errorf("se obtuvo %+v (%v)", decodificado, err)
+	}
+	if strings.Contains(lineas[0], "usuario") || !strings.Contains(lineas[0], `"duracion":1500`) {
+		t.Errorf("se obtuvo %s", lineas[0])
+	}
+}
+
+func TestRegistradorDeAccesosApache(t *testing.T) {
+	fecha := time.Date(2021, 10, 10, 13, 55, 36, 0, time.FixedZone("", -3*60*60))
+	pruebas := []struct {
+		registro RegistroDeAcceso
+		esperado string
+	}{
+		{
+			RegistroDeAcceso{Fecha: fecha, Metodo: "GET", URI: "/personas/5", Protocolo: "HTTP/1.1", Estado: 200, Bytes: 2326,
+				IPRemota: "127.0.0.1", Usuario: "ana", Referente: "http://ejemplo.com/", AgenteDeUsuario: `Mozilla/5.0 "x"`},
+			`127.0.0.1 - ana [10/Oct/2021:13:55:36 -0300] "GET /personas/5 HTTP/1.1" 200 2326 "http://ejemplo.com/" "Mozilla/5.0 \"x\""` + "\n",
+		},
+		{
+			RegistroDeAcceso{Fecha: fecha, Metodo: "DELETE", URI: "/personas/5", Protocolo: "HTTP/2.0", Estado: 204, IPRemota: "::1"},
+			`::1 - - [10/Oct/2021:13:55:36 -0300] "DELETE /personas/5 HTTP/2.0" 204 - "-" "-"` + "\n",
+		},
+	}
+	for _, p := range pruebas {
+		var salida bytes.Buffer
+		RegistradorDeAccesosApache(&salida)(p.registro)
+		if salida.String() != p.esperado {
+			t.Errorf("se obtuvo %q, se esperaba %q", salida.String(), p.esperado)
+		}
+	}
+}
+
+// registradorEstructuradoDePrueba almacena los mensajes recibidos.
+type registradorEstructuradoDePrueba struct {
+	mensajes []string
+	args     [][]interface{}
+}
+
+func (o *registradorEstructuradoDePrueba) Info(mensaje string, args ...interface{}) {
+	o.mensajes = append(o.mensajes, mensaje)
+	o.args = append(o.args, args)
+}
+
+func TestRegistradorDeAccesosEstructurado(t *testing.T) {
+	registrador := &registradorEstructuradoDePrueba{}
+	RegistradorDeAccesosEstructurado(registrador)(RegistroDeAcceso{
+		Metodo: "GET", URI: "/personas/5", Patron: "/personas/{id}", Estado: 404, Bytes: 10,
+		Duracion: time.Second, IPRemota: "127.0.0.1", IDDeSolicitud: "abc",
+	})
+
+	if len(registrador.mensajes) != 1 || registrador.mensajes[0] != "acceso" {
+		t.Fatalf("se obtuvo %v", registrador.mensajes)
+	}
+	args := registrador.args[0]
+	if len(args)%2 != 0 {
+		t.Fatalf("los argumentos no son pares clave/valor: %v", args)
+	}
+	valores := make(map[interface{}]interface{})
+	for i := 0; i < len(args); i += 2 {
+		valores[args[i]] = args[i+1]
+	}
+	if valores["metodo"] != "GET" || valores["patron"] != "/personas/{id}" || valores["estado"] != 404 ||
+		valores["duracion"] != time.Second || valores["idDeSolicitud"] != "abc" {
+		t.Errorf("se obtuvo %v", valores)
+	}
+}
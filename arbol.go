@@ -0,0 +1,120 @@
+package apirest
+
+import "strings"
+
+// nodo es un nodo del árbol de patrones de rutas. Cada nodo representa una
+// parte (segmento) de un patrón de ruta; el camino desde la raíz hasta un
+// nodo con detalle forma el patrón de ruta completo.
+// Al buscar una ruta, las partes fijas siempre tienen precedencia sobre las
+// partes variables: "/personas/nuevos" se prefiere sobre "/personas/{id}".
+// Entre las partes variables, las que poseen restricción se prueban antes que
+// la que no la posee: "/personas/{id:int}" se prefiere sobre "/personas/{v}".
+// Por último, se prueba la parte comodín, que captura el resto de la ruta:
+// "/archivos/{ruta...}".
+type nodo struct {
+	clave       string               // parte del patrón de ruta que representa el nodo ("personas", "{v}", "{v:int}", ...)
+	restriccion *restriccion         // restricción de la parte variable (si existe)
+	estaticos   map[string]*nodo     // hijos cuya parte es fija
+	variables   []*nodo              // hijos cuya parte es variable (el hijo sin restricción siempre es el último)
+	comodin     *nodo                // hijo cuya parte es comodín ("{v...}"); siempre es un nodo final
+	detalle     *patronDeRutaDetalle // detalle del patrón de ruta que finaliza en este nodo (si existe)
+}
+
+// insertar agrega al árbol las partes de un patrón de ruta y asigna el
+// detalle al nodo final.
+func (o *nodo) insertar(partes []string, detallePtr *patronDeRutaDetalle) {
+	actual := o
+	for pos, parte := range partes {
+		if parte == "{v...}" {
+			if actual.comodin == nil {
+				actual.comodin = &nodo{clave: parte}
+			}
+			actual = actual.comodin
+			continue
+		}
+		if strings.HasPrefix(parte, "{v") {
+			actual = actual.hijoVariable(parte, detallePtr.restriccionDeVariable(pos))
+			continue
+		}
+
+		if actual.estaticos == nil {
+			actual.estaticos = make(map[string]*nodo)
+		}
+		hijo, ok := actual.estaticos[parte]
+		if !ok {
+			hijo = &nodo{clave: parte}
+			actual.estaticos[parte] = hijo
+		}
+		actual = hijo
+	}
+
+	actual.detalle = detallePtr
+}
+
+// buscar devuelve el detalle del patrón de ruta que coincide con las partes
+// de la ruta recibida. Realiza una única búsqueda por parte, intentando
+// primero la parte fija y luego la parte variable (en caso que la parte fija
+// no conduzca a un patrón de ruta existente). Si ninguna conduce a un patrón
+// de ruta existente, se utiliza la parte comodín (si existe).
+func (o *nodo) buscar(partes []string) *patronDeRutaDetalle {
+	if len(partes) == 0 {
+		if o.detalle == nil && o.comodin != nil {
+			return o.comodin.detalle
+		}
+		return o.detalle
+	}
+
+	if hijo, ok := o.estaticos[partes[0]]; ok {
+		if detallePtr := hijo.buscar(partes[1:]); detallePtr != nil {
+			return detallePtr
+		}
+	}
+	if partes[0] != "" {
+		for _, hijo := range o.variables {
+			if hijo.restriccion != nil && !hijo.restriccion.validar(partes[0]) {
+				continue
+			}
+			if detallePtr := hijo.buscar(partes[1:]); detallePtr != nil {
+				return detallePtr
+			}
+		}
+	}
+	if o.comodin != nil {
+		return o.comodin.detalle
+	}
+
+	return nil
+}
+
+// hijoVariable devuelve el hijo variable que corresponde a la clave recibida.
+// Si no existe, lo crea manteniendo al hijo sin restricción en último lugar.
+func (o *nodo) hijoVariable(clave string, r *restriccion) *nodo {
+	for _, hijo := range o.variables {
+		if hijo.clave == clave {
+			return hijo
+		}
+	}
+
+	hijo := &nodo{clave: clave, restriccion: r}
+	pos := len(o.variables)
+	if pos > 0 && o.variables[pos-1].restriccion == nil && r != nil {
+		pos--
+	}
+	o.variables = append(o.variables, nil)
+	copy(o.variables[pos+1:], o.variables[pos:])
+	o.variables[pos] = hijo
+
+	return hijo
+}
+
+// dividirRuta divide una ruta en sus partes, quitando las barras iniciales
+// y finales: "/personas/1/" se divide en ["personas", "1"].
+func dividirRuta(ruta string) []string {
+	ruta = strings.TrimPrefix(ruta, "/")
+	ruta = strings.TrimSuffix(ruta, "/")
+	if ruta == "" {
+		return nil
+	}
+
+	return strings.Split(ruta, "/")
+}
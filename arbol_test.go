@@ -0,0 +1,207 @@
+package apirest
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// enrutadorDePrueba crea un enrutador con un endpoint GET por cada ruta.
+func enrutadorDePrueba(t testing.TB, rutas ...string) *enrutador {
+	r := CrearEnrutador()
+	for _, ruta := range rutas {
+		r.GET(ruta, func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+			return nil, nil
+		})
+	}
+	if err := r.Validar(); err != nil {
+		t.Fatalf("no se registraron las rutas: %v", err)
+	}
+
+	return r
+}
+
+// detalleRegistrado devuelve el detalle del patrón de ruta con el que se
+// registró la ruta recibida.
+func detalleRegistrado(t testing.TB, r *enrutador, ruta string) *patronDeRutaDetalle {
+	pr, _, err := r.rutaAPatronDeRuta(ruta)
+	if err != nil {
+		t.Fatalf("%s: %v", ruta, err)
+	}
+
+	return r.patronesDeRutas[pr]
+}
+
+func TestBuscarPatronDeRuta(t *testing.T) {
+	r := enrutadorDePrueba(t,
+		"/personas",
+		"/personas/nuevos",
+		"/personas/{id:int}",
+		"/personas/{nombre}",
+		"/personas/{nombre}/domicilios",
+		"/sesiones/{id:uuid}",
+		"/sesiones/{clave}",
+		"/articulos/nuevos",
+		"/articulos/{id}/comentarios",
+		"/archivos/publicos/indice",
+		"/archivos/{ruta...}",
+	)
+
+	pruebas := []struct {
+		ruta      string
+		patron    string
+		variables map[string]string
+	}{
+		// la parte fija tiene precedencia sobre las partes variables
+		{"/personas", "/personas", map[string]string{}},
+		{"/personas/", "/personas", map[string]string{}},
+		{"/personas/nuevos", "/personas/nuevos", map[string]string{}},
+
+		// la parte variable con restricción tiene precedencia sobre la parte
+		// variable sin restricción
+		{"/personas/5", "/personas/{id:int}", map[string]string{"id": "5"}},
+		{"/personas/ana", "/personas/{nombre}", map[string]string{"nombre": "ana"}},
+		{"/sesiones/0f8fad5b-d9cb-469f-a165-70867728950e", "/sesiones/{id:uuid}", map[string]string{"id": "0f8fad5b-d9cb-469f-a165-70867728950e"}},
+		{"/sesiones/abc", "/sesiones/{clave}", map[string]string{"clave": "abc"}},
+
+		// retroceso: la parte fija no conduce a un patrón de ruta existente,
+		// por lo que se prueba la parte variable
+		{"/articulos/nuevos/comentarios", "/articulos/{id}/comentarios", map[string]string{"id": "nuevos"}},
+		{"/personas/5/domicilios", "/personas/{nombre}/domicilios", map[string]string{"nombre": "5"}},
+
+		// la parte comodín se prueba en último lugar y captura el resto de la
+		// ruta
+		{"/archivos/publicos/indice", "/archivos/publicos/indice", map[string]string{}},
+		{"/archivos/publicos/otro", "/archivos/{ruta...}", map[string]string{"ruta": "publicos/otro"}},
+		{"/archivos/2021/informe.pdf", "/archivos/{ruta...}", map[string]string{"ruta": "2021/informe.pdf"}},
+		{"/archivos", "/archivos/{ruta...}", map[string]string{"ruta": ""}},
+
+		// rutas inexistentes
+		{"/", "", nil},
+		{"/otros", "", nil},
+		{"/personas/5/telefonos", "", nil},
+		{"/articulos/nuevos/otros", "", nil},
+		{"/personas//domicilios", "", nil},
+	}
+	for _, p := range pruebas {
+		detallePtr, variables, ok := r.buscarPatronDeRuta(p.ruta)
+		if p.patron == "" {
+			if ok {
+				t.Errorf("%s: se encontró el patrón %v, no se esperaba ninguno", p.ruta, detallePtr.variables)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("%s: no se encontró el patrón, se esperaba %s", p.ruta, p.patron)
+			continue
+		}
+		if detallePtr != detalleRegistrado(t, r, p.patron) || !reflect.DeepEqual(variables, p.variables) {
+			t.Errorf("%s: se obtuvo %v, se esperaba %s %v", p.ruta, variables, p.patron, p.variables)
+		}
+	}
+}
+
+func TestBuscarPatronDeRutaIndependienteDelOrdenDeRegistro(t *testing.T) {
+	rutas := []string{"/personas/{nombre}", "/personas/{id:int}", "/personas/nuevos", "/personas/{ruta...}"}
+	esperados := map[string]string{
+		"/personas/nuevos": "/personas/nuevos",
+		"/personas/5":      "/personas/{id:int}",
+		"/personas/ana":    "/personas/{nombre}",
+		"/personas/a/b":    "/personas/{ruta...}",
+	}
+
+	// registrar las rutas en orden directo e inverso
+	for _, orden := range [][]string{rutas, {rutas[3], rutas[2], rutas[1], rutas[0]}} {
+		r := enrutadorDePrueba(t, orden...)
+		for ruta, patron := range esperados {
+			detallePtr, _, ok := r.buscarPatronDeRuta(ruta)
+			if !ok || detallePtr != detalleRegistrado(t, r, patron) {
+				t.Errorf("orden %v, %s: se esperaba %s", orden, ruta, patron)
+			}
+		}
+	}
+}
+
+func TestDividirRuta(t *testing.T) {
+	pruebas := map[string][]string{
+		"":               nil,
+		"/":              nil,
+		"/personas":      {"personas"},
+		"/personas/1/":   {"personas", "1"},
+		"personas/1/a/b": {"personas", "1", "a", "b"},
+	}
+	for ruta, esperado := range pruebas {
+		if partes := dividirRuta(ruta); !reflect.DeepEqual(partes, esperado) {
+			t.Errorf("%q: se obtuvo %q, se esperaba %q", ruta, partes, esperado)
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Comparación con la búsqueda anterior (recorrido del mapa de patrones).
+
+// buscarEnMapa es la búsqueda de patrones de rutas anterior al árbol: recorre
+// el mapa de patrones comparando cada parte de la ruta recibida.
+func buscarEnMapa(patrones map[patronDeRuta]*patronDeRutaDetalle, rutaRecibida string) (*patronDeRutaDetalle, map[string]string, bool) {
+	var partesRutaRecibida = dividirRuta(rutaRecibida)
+
+	for pr, detallePtr := range patrones {
+		partesPatronDeRuta := strings.Split(pr.string(), "/")[1:]
+		if len(partesRutaRecibida) != len(partesPatronDeRuta) {
+			continue
+		}
+
+		encontrado := true
+		for i, partePatronActual := range partesPatronDeRuta {
+			if partePatronActual != partesRutaRecibida[i] && partePatronActual != "{v}" {
+				encontrado = false
+				break
+			}
+		}
+		if encontrado {
+			var variables = make(map[string]string, len(detallePtr.variables))
+			for _, variable := range detallePtr.variables {
+				variables[variable.nombre] = partesRutaRecibida[variable.posicion]
+			}
+
+			return detallePtr, variables, true
+		}
+	}
+
+	return nil, nil, false
+}
+
+// rutasDeReferencia devuelve 520 rutas (130 recursos con 4 rutas cada uno).
+func rutasDeReferencia() []string {
+	var rutas []string
+	for i := 0; i < 130; i++ {
+		rutas = append(rutas,
+			fmt.Sprintf("/recurso%d", i),
+			fmt.Sprintf("/recurso%d/nuevos", i),
+			fmt.Sprintf("/recurso%d/{id}", i),
+			fmt.Sprintf("/recurso%d/{id}/detalle", i),
+		)
+	}
+
+	return rutas
+}
+
+func BenchmarkBuscar(b *testing.B) {
+	r := enrutadorDePrueba(b, rutasDeReferencia()...)
+	rutas := []string{"/recurso0", "/recurso64/nuevos", "/recurso129/77", "/recurso129/77/detalle", "/inexistente/1"}
+
+	b.Run("arbol", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r.buscarPatronDeRuta(rutas[i%len(rutas)])
+		}
+	})
+	b.Run("mapa", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buscarEnMapa(r.patronesDeRutas, rutas[i%len(rutas)])
+		}
+	})
+}
@@ -0,0 +1,399 @@
+package apirest
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// identidad es el resultado de una autenticación exitosa, almacenado en el
+// contexto de la solicitud por los interceptores de autenticación.
+type identidad struct {
+	usuario  string                 // usuario autenticado (usuario básico, dueño de la clave de API o reclamo "sub")
+	reclamos map[string]interface{} // reclamos verificados del JWT (sólo con InterceptorJWT)
+	alcances []string               // alcances otorgados (reclamos "scope" o "scp" del JWT)
+}
+
+// ObtenerUsuario devuelve el usuario autenticado por los interceptores de
+// autenticación: el usuario de la autenticación básica, el dueño de la clave
+// de API o el reclamo "sub" del JWT.
+func ObtenerUsuario(r *http.Request) string {
+	if id, ok := r.Context().Value(claveIdentidad).(*identidad); ok {
+		return id.usuario
+	}
+	return ""
+}
+
+// ObtenerReclamos devuelve los reclamos (claims) verificados del JWT de la
+// solicitud. Sólo posee valor dentro de los endpoints que utilizan
+// InterceptorJWT.
+func ObtenerReclamos(r *http.Request) map[string]interface{} {
+	if id, ok := r.Context().Value(claveIdentidad).(*identidad); ok {
+		return id.reclamos
+	}
+	return nil
+}
+
+// asignarIdentidad devuelve la solicitud con la identidad en su contexto.
+func asignarIdentidad(r *http.Request, id *identidad) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), claveIdentidad, id))
+}
+
+// esPreflightDelEnrutador determina si la solicitud es una verificación previa
+// CORS (preflight) que responde el propio enrutador (ver CORSActivar). Los
+// navegadores no envían credenciales en estas solicitudes, por lo que los
+// interceptores de autenticación no las verifican.
+func esPreflightDelEnrutador(r *http.Request) bool {
+	esPreflight, _ := r.Context().Value(clavePreflightCORS).(bool)
+	return esPreflight
+}
+
+// errorDeAutenticacion escribe el campo de cabecera "WWW-Authenticate" con el
+// desafío recibido y devuelve un error de tipo 401 (Sin autorización).
+func errorDeAutenticacion(w http.ResponseWriter, desafio, codigo, formato string, args ...interface{}) *errorAPIREST {
+	w.Header().Set("WWW-Authenticate", desafio)
+	err := ErrorNuevoSinAutorizacion(formato, args...).AsignarCodigo(codigo)
+	err.asignarRastro(1)
+
+	return err
+}
+
+// -----------------------------------------------------------------------------
+// Autenticación básica.
+
+// InterceptorAutenticacionBasica devuelve un interceptor (middleware) que
+// verifica la autenticación básica de HTTP (RFC 7617) con la función recibida
+// (ver CredencialesBasicas). Si las credenciales no existen o son inválidas, se
+// responde 401 (Sin autorización) con el desafío "WWW-Authenticate: Basic".
+// Las verificaciones previas CORS que responde el enrutador no se autentican.
+// Ejemplo:
+// 	r.Usar(apirest.InterceptorAutenticacionBasica("administracion",
+// 		apirest.CredencialesBasicas(map[string]string{"ana": "secreto"})))
+func InterceptorAutenticacionBasica(reino string, validar func(usuario, clave string) bool) InterceptorFunc {
+	desafio := fmt.Sprintf(`Basic realm=%q, charset="UTF-8"`, reino)
+
+	return func(manejadorFunc ManejadorFunc) ManejadorFunc {
+		return func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+			if esPreflightDelEnrutador(r) {
+				return manejadorFunc(w, r)
+			}
+
+			usuario, clave, ok := r.BasicAuth()
+			if !ok {
+				return nil, errorDeAutenticacion(w, desafio, "apirest.autenticacionRequerida", "La solicitud requiere autenticación")
+			}
+			if !validar(usuario, clave) {
+				return nil, errorDeAutenticacion(w, desafio, "apirest.credencialesInvalidas", "Las credenciales son inválidas")
+			}
+
+			return manejadorFunc(w, asignarIdentidad(r, &identidad{usuario: usuario}))
+		}
+	}
+}
+
+// CredencialesBasicas devuelve una función de validación de la autenticación
+// básica a partir de un mapa de usuarios y claves. Las claves se comparan en
+// tiempo constante.
+func CredencialesBasicas(usuarios map[string]string) func(usuario, clave string) bool {
+	return func(usuario, clave string) bool {
+		esperada, ok := usuarios[usuario]
+		return ok && subtle.ConstantTimeCompare([]byte(esperada), []byte(clave)) == 1
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Claves de API.
+
+// InterceptorClaveDeAPI devuelve un interceptor (middleware) que verifica la
+// clave de API recibida en el campo de cabecera indicado (por ejemplo,
+// "X-API-Key"). Las claves se reciben en un mapa de clave y usuario (dueño de
+// la clave) y se comparan en tiempo constante. Si la clave no existe o es
+// inválida, se responde 401 (Sin autorización). Las verificaciones previas
+// CORS que responde el enrutador no se autentican.
+// Ejemplo:
+// 	r.Usar(apirest.InterceptorClaveDeAPI("X-API-Key", map[string]string{"c1a2v3e4": "facturacion"}))
+func InterceptorClaveDeAPI(campoDeCabecera string, claves map[string]string) InterceptorFunc {
+	desafio := fmt.Sprintf(`APIKey header=%q`, campoDeCabecera)
+
+	return func(manejadorFunc ManejadorFunc) ManejadorFunc {
+		return func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+			if esPreflightDelEnrutador(r) {
+				return manejadorFunc(w, r)
+			}
+
+			recibida := r.Header.Get(campoDeCabecera)
+			if recibida == "" {
+				return nil, errorDeAutenticacion(w, desafio, "apirest.autenticacionRequerida", "La solicitud requiere una clave de API")
+			}
+
+			// recorrer todas las claves para no revelar, por el tiempo de
+			// respuesta, cuántos caracteres coinciden
+			var usuario string
+			var encontrada bool
+			for clave, propietario := range claves {
+				if subtle.ConstantTimeCompare([]byte(clave), []byte(recibida)) == 1 {
+					usuario, encontrada = propietario, true
+				}
+			}
+			if !encontrada {
+				return nil, errorDeAutenticacion(w, desafio, "apirest.credencialesInvalidas", "La clave de API es inválida")
+			}
+
+			return manejadorFunc(w, asignarIdentidad(r, &identidad{usuario: usuario}))
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------
+// JWT (JSON Web Token).
+
+// OpcionesJWT establece el comportamiento de InterceptorJWT. Debe indicarse la
+// clave HS256 (no vacía) o la clave RS256 (el algoritmo del token debe coincidir con la
+// clave indicada).
+type OpcionesJWT struct {
+	ClaveHS256 []byte         // secreto compartido para los tokens firmados con HS256
+	ClaveRS256 *rsa.PublicKey // clave pública para los tokens firmados con RS256
+	Emisor     string         // valor requerido del reclamo "iss" (es opcional)
+	Audiencia  string         // valor requerido del reclamo "aud" (es opcional)
+	Tolerancia time.Duration  // tolerancia en la verificación de "exp" y "nbf" (diferencias de reloj)
+	Reino      string         // reino informado en el desafío "WWW-Authenticate" (por defecto, "apirest")
+}
+
+// InterceptorJWT devuelve un interceptor (middleware) que verifica el JWT
+// recibido en el campo de cabecera "Authorization: Bearer <token>" (RFC 6750):
+// la firma (HS256 o RS256) y los reclamos "exp", "nbf", "iss" y "aud". Los
+// reclamos verificados se almacenan en el contexto de la solicitud (ver
+// ObtenerReclamos y ObtenerUsuario). Si el token no existe o es inválido, se
+// responde 401 (Sin autorización) con el desafío "WWW-Authenticate: Bearer".
+// Las verificaciones previas CORS (preflight) que responde el enrutador no se
+// autentican, ya que los navegadores no envían credenciales en ellas.
+// Si no se indica la clave HS256 (no vacía) ni la clave RS256, el interceptor
+// es inválido: el problema se informa como un conflicto de registro del
+// enrutador (ConflictoInterceptorInvalido, ver Validar).
+// Ejemplo:
+// 	r.Usar(apirest.InterceptorJWT(apirest.OpcionesJWT{ClaveHS256: secreto, Emisor: "https://auth.ejemplo.com"}))
+// 	r.DELETE("/personas/{id}", borrarPersona).Interceptores(apirest.InterceptorAlcances("personas:borrar"))
+func InterceptorJWT(opciones OpcionesJWT) InterceptorFunc {
+	if len(opciones.ClaveHS256) == 0 && opciones.ClaveRS256 == nil {
+		return interceptorInvalido("debe indicarse la clave HS256 o la clave RS256 para verificar los JWT")
+	}
+	if opciones.Reino == "" {
+		opciones.Reino = "apirest"
+	}
+	desafio := fmt.Sprintf(`Bearer realm=%q`, opciones.Reino)
+
+	return func(manejadorFunc ManejadorFunc) ManejadorFunc {
+		return func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+			if esPreflightDelEnrutador(r) {
+				return manejadorFunc(w, r)
+			}
+
+			autorizacion := r.Header.Get("Authorization")
+			if len(autorizacion) < 7 || !strings.EqualFold(autorizacion[:7], "Bearer ") {
+				return nil, errorDeAutenticacion(w, desafio, "apirest.autenticacionRequerida", "La solicitud requiere autenticación")
+			}
+
+			reclamos, codigo, err := opciones.verificar(strings.TrimSpace(autorizacion[7:]), time.Now())
+			if err != nil {
+				return nil, errorDeAutenticacion(w, desafio+`, error="invalid_token"`, codigo, "El token de acceso es inválido").
+					AsignarMensajeTecnico("%v", err)
+			}
+
+			id := &identidad{reclamos: reclamos, alcances: alcancesDeReclamos(reclamos)}
+			id.usuario, _ = reclamos["sub"].(string)
+
+			return manejadorFunc(w, asignarIdentidad(r, id))
+		}
+	}
+}
+
+// verificar verifica la firma y los reclamos del token. Devuelve los reclamos
+// o, si el token es inválido, el código de error y el motivo.
+func (o OpcionesJWT) verificar(token string, ahora time.Time) (map[string]interface{}, string, error) {
+	partes := strings.Split(token, ".")
+	if len(partes) != 3 {
+		return nil, "apirest.tokenInvalido", fmt.Errorf("el token no posee tres partes")
+	}
+
+	var cabecera struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodificarParteJWT(partes[0], &cabecera); err != nil {
+		return nil, "apirest.tokenInvalido", fmt.Errorf("la cabecera del token es inválida: %v", err)
+	}
+	firma, err := base64.RawURLEncoding.DecodeString(partes[2])
+	if err != nil {
+		return nil, "apirest.tokenInvalido", fmt.Errorf("la firma del token es inválida: %v", err)
+	}
+
+	// verificar la firma con el algoritmo de la clave configurada (nunca con el
+	// algoritmo que indica el token, para evitar "none" o la confusión de claves)
+	contenido := []byte(partes[0] + "." + partes[1])
+	switch {
+	case cabecera.Alg == "HS256" && len(o.ClaveHS256) > 0:
+		mac := hmac.New(sha256.New, o.ClaveHS256)
+		mac.Write(contenido)
+		if !hmac.Equal(firma, mac.Sum(nil)) {
+			return nil, "apirest.tokenInvalido", fmt.Errorf("la firma del token es inválida")
+		}
+	case cabecera.Alg == "RS256" && o.ClaveRS256 != nil:
+		resumen := sha256.Sum256(contenido)
+		if err := rsa.VerifyPKCS1v15(o.ClaveRS256, crypto.SHA256, resumen[:], firma); err != nil {
+			return nil, "apirest.tokenInvalido", fmt.Errorf("la firma del token es inválida")
+		}
+	default:
+		return nil, "apirest.tokenInvalido", fmt.Errorf("el algoritmo %q no es admitido", cabecera.Alg)
+	}
+
+	var reclamos map[string]interface{}
+	if err := decodificarParteJWT(partes[1], &reclamos); err != nil {
+		return nil, "apirest.tokenInvalido", fmt.Errorf("los reclamos del token son inválidos: %v", err)
+	}
+
+	// verificar la vigencia: el token expira en el instante "exp" (RFC 7519)
+	exp, existe, err := fechaDeReclamo(reclamos, "exp")
+	if err != nil {
+		return nil, "apirest.tokenInvalido", err
+	}
+	if existe && !ahora.Before(exp.Add(o.Tolerancia)) {
+		return nil, "apirest.tokenExpirado", fmt.Errorf("el token expiró")
+	}
+	nbf, existe, err := fechaDeReclamo(reclamos, "nbf")
+	if err != nil {
+		return nil, "apirest.tokenInvalido", err
+	}
+	if existe && ahora.Before(nbf.Add(-o.Tolerancia)) {
+		return nil, "apirest.tokenInvalido", fmt.Errorf("el token aún no es válido")
+	}
+
+	// verificar el emisor y la audiencia
+	if o.Emisor != "" && reclamos["iss"] != o.Emisor {
+		return nil, "apirest.tokenInvalido", fmt.Errorf("el emisor del token es inválido")
+	}
+	if o.Audiencia != "" && !contieneAudiencia(reclamos["aud"], o.Audiencia) {
+		return nil, "apirest.tokenInvalido", fmt.Errorf("la audiencia del token es inválida")
+	}
+
+	return reclamos, "", nil
+}
+
+// decodificarParteJWT decodifica una parte del token (base64url sin relleno y
+// JSON).
+func decodificarParteJWT(parte string, destino interface{}) error {
+	texto, err := base64.RawURLEncoding.DecodeString(parte)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(texto, destino)
+}
+
+// fechaDeReclamo devuelve la fecha del reclamo recibido ("exp" o "nbf"), si
+// existe. El reclamo debe ser una fecha numérica (segundos desde 1970).
+func fechaDeReclamo(reclamos map[string]interface{}, nombre string) (time.Time, bool, error) {
+	valor, existe := reclamos[nombre]
+	if !existe {
+		return time.Time{}, false, nil
+	}
+	segundos, ok := valor.(float64)
+	if !ok {
+		return time.Time{}, true, fmt.Errorf("el reclamo %q no es una fecha numérica: %v", nombre, valor)
+	}
+
+	return time.Unix(int64(segundos), 0), true, nil
+}
+
+// contieneAudiencia verifica que el reclamo "aud" (un texto o una lista de
+// textos) contenga la audiencia recibida.
+func contieneAudiencia(aud interface{}, audiencia string) bool {
+	switch valor := aud.(type) {
+	case string:
+		return valor == audiencia
+	case []interface{}:
+		for _, elemento := range valor {
+			if elemento == audiencia {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// alcancesDeReclamos devuelve los alcances del reclamo "scope" (textos
+// separados por espacios) o "scp" (lista de textos).
+func alcancesDeReclamos(reclamos map[string]interface{}) []string {
+	if scope, ok := reclamos["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+
+	var alcances []string
+	if scp, ok := reclamos["scp"].([]interface{}); ok {
+		for _, elemento := range scp {
+			if alcance, ok := elemento.(string); ok {
+				alcances = append(alcances, alcance)
+			}
+		}
+	}
+
+	return alcances
+}
+
+// -----------------------------------------------------------------------------
+// Alcances.
+
+// InterceptorAlcances devuelve un interceptor (middleware) que verifica que el
+// JWT de la solicitud (ver InterceptorJWT) otorgue todos los alcances
+// recibidos. Si la solicitud no fue autenticada, se responde 401 (Sin
+// autorización); si no se otorgan los alcances, se responde 403 (Sin
+// privilegios) con el desafío "WWW-Authenticate: Bearer error="insufficient_scope"".
+// Debe procesarse después de InterceptorJWT.
+func InterceptorAlcances(alcances ...string) InterceptorFunc {
+	desafioSinIdentidad := fmt.Sprintf(`Bearer scope=%q`, strings.Join(alcances, " "))
+	desafio := fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, strings.Join(alcances, " "))
+
+	return func(manejadorFunc ManejadorFunc) ManejadorFunc {
+		return func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+			if esPreflightDelEnrutador(r) {
+				return manejadorFunc(w, r)
+			}
+
+			id, ok := r.Context().Value(claveIdentidad).(*identidad)
+			if !ok {
+				return nil, errorDeAutenticacion(w, desafioSinIdentidad, "apirest.autenticacionRequerida", "La solicitud requiere autenticación")
+			}
+
+			for _, alcance := range alcances {
+				if !contieneAlcance(id.alcances, alcance) {
+					w.Header().Set("WWW-Authenticate", desafio)
+					return nil, ErrorNuevoSinPrivilegios("La solicitud requiere el alcance %v", alcance).
+						AsignarCodigo("apirest.alcanceInsuficiente").
+						AsignarValoresAdicionales(alcances...)
+				}
+			}
+
+			return manejadorFunc(w, r)
+		}
+	}
+}
+
+// contieneAlcance determina si el alcance se encuentra entre los alcances
+// otorgados (distingue mayúsculas y minúsculas, RFC 6749).
+func contieneAlcance(otorgados []string, alcance string) bool {
+	for _, otorgado := range otorgados {
+		if otorgado == alcance {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,363 @@
+package apirest
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// crearJWT crea un token con el algoritmo y los reclamos recibidos, firmado
+// con la función recibida (sin firma si es nil).
+func crearJWT(t *testing.T, alg string, reclamos map[string]interface{}, firmar func(contenido []byte) []byte) string {
+	t.Helper()
+
+	cabecera, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	cuerpo, err := json.Marshal(reclamos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contenido := base64.RawURLEncoding.EncodeToString(cabecera) + "." + base64.RawURLEncoding.EncodeToString(cuerpo)
+	var firma []byte
+	if firmar != nil {
+		firma = firmar([]byte(contenido))
+	}
+
+	return contenido + "." + base64.RawURLEncoding.EncodeToString(firma)
+}
+
+// firmaHS256 devuelve la función que firma con HMAC SHA-256.
+func firmaHS256(clave []byte) func(contenido []byte) []byte {
+	return func(contenido []byte) []byte {
+		mac := hmac.New(sha256.New, clave)
+		mac.Write(contenido)
+		return mac.Sum(nil)
+	}
+}
+
+// firmaRS256 devuelve la función que firma con RSA SHA-256.
+func firmaRS256(t *testing.T, clave *rsa.PrivateKey) func(contenido []byte) []byte {
+	return func(contenido []byte) []byte {
+		resumen := sha256.Sum256(contenido)
+		firma, err := rsa.SignPKCS1v15(rand.Reader, clave, crypto.SHA256, resumen[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return firma
+	}
+}
+
+// manejadorDeIdentidad responde el usuario y los reclamos autenticados.
+func manejadorDeIdentidad(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	return map[string]interface{}{"usuario": ObtenerUsuario(r), "reclamos": ObtenerReclamos(r)}, nil
+}
+
+func TestInterceptorAutenticacionBasica(t *testing.T) {
+	r := CrearEnrutador()
+	r.Usar(InterceptorAutenticacionBasica("administracion", CredencialesBasicas(map[string]string{"ana": "secreto"})))
+	r.GET("/personas", manejadorDeIdentidad)
+
+	pruebas := []struct {
+		nombre        string
+		autorizacion  string
+		estado        int
+		codigo        string
+		usuarioEnBody string
+	}{
+		{"válida", "Basic " + base64.StdEncoding.EncodeToString([]byte("ana:secreto")), 200, "", `"usuario":"ana"`},
+		{"sin credenciales", "", 401, "apirest.autenticacionRequerida", ""},
+		{"clave inválida", "Basic " + base64.StdEncoding.EncodeToString([]byte("ana:otra")), 401, "apirest.credencialesInvalidas", ""},
+		{"usuario inexistente", "Basic " + base64.StdEncoding.EncodeToString([]byte("juan:secreto")), 401, "apirest.credencialesInvalidas", ""},
+	}
+	for _, p := range pruebas {
+		t.Run(p.nombre, func(t *testing.T) {
+			w := solicitar(r, "GET", "/personas", map[string]string{"Authorization": p.autorizacion})
+			if w.Code != p.estado {
+				t.Fatalf("se obtuvo %d %s", w.Code, w.Body.String())
+			}
+			if p.codigo == "" {
+				if !strings.Contains(w.Body.String(), p.usuarioEnBody) {
+					t.Errorf("se obtuvo %s", w.Body.String())
+				}
+				return
+			}
+			if c := decodificarCuerpoDeError(t, w); c.Error.Codigo != p.codigo ||
+				w.Header().Get("WWW-Authenticate") != `Basic realm="administracion", charset="UTF-8"` {
+				t.Errorf("se obtuvo %q %v", c.Error.Codigo, w.Header())
+			}
+		})
+	}
+}
+
+func TestInterceptorClaveDeAPI(t *testing.T) {
+	r := CrearEnrutador()
+	r.Usar(InterceptorClaveDeAPI("X-API-Key", map[string]string{"c1a2v3e4": "facturacion", "otra": "ventas"}))
+	r.GET("/personas", manejadorDeIdentidad)
+
+	if w := solicitar(r, "GET", "/personas", map[string]string{"X-API-Key": "c1a2v3e4"}); w.Code != 200 ||
+		!strings.Contains(w.Body.String(), `"usuario":"facturacion"`) {
+		t.Errorf("se obtuvo %d %s", w.Code, w.Body.String())
+	}
+	for clave, codigo := range map[string]string{"": "apirest.autenticacionRequerida", "c1a2v3e": "apirest.credencialesInvalidas"} {
+		w := solicitar(r, "GET", "/personas", map[string]string{"X-API-Key": clave})
+		if c := decodificarCuerpoDeError(t, w); w.Code != 401 || c.Error.Codigo != codigo ||
+			w.Header().Get("WWW-Authenticate") != `APIKey header="X-API-Key"` {
+			t.Errorf("%q: se obtuvo %d %q %v", clave, w.Code, c.Error.Codigo, w.Header())
+		}
+	}
+}
+
+func TestVerificarJWT(t *testing.T) {
+	claveHS256 := []byte("secreto-compartido")
+	claveRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otraClaveRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicaDER, _ := x509.MarshalPKIXPublicKey(&claveRSA.PublicKey)
+
+	ahora := time.Unix(1600000000, 0)
+	reclamos := func(pares ...interface{}) map[string]interface{} {
+		m := map[string]interface{}{"sub": "ana"}
+		for i := 0; i < len(pares); i += 2 {
+			m[pares[i].(string)] = pares[i+1]
+		}
+		return m
+	}
+	opcionesHS256 := OpcionesJWT{ClaveHS256: claveHS256}
+	opcionesRS256 := OpcionesJWT{ClaveRS256: &claveRSA.PublicKey}
+
+	// token con los reclamos de otro usuario y la firma original
+	partes := strings.Split(crearJWT(t, "HS256", reclamos(), firmaHS256(claveHS256)), ".")
+	otrosReclamos := strings.Split(crearJWT(t, "HS256", reclamos("sub", "juan"), nil), ".")[1]
+	modificado := partes[0] + "." + otrosReclamos + "." + partes[2]
+
+	pruebas := []struct {
+		nombre   string
+		opciones OpcionesJWT
+		token    string
+		codigo   string
+	}{
+		{"HS256", opcionesHS256, crearJWT(t, "HS256", reclamos(), firmaHS256(claveHS256)), ""},
+		{"RS256", opcionesRS256, crearJWT(t, "RS256", reclamos(), firmaRS256(t, claveRSA)), ""},
+		{"HS256 con otra clave", opcionesHS256, crearJWT(t, "HS256", reclamos(), firmaHS256([]byte("otra"))), "apirest.tokenInvalido"},
+		{"RS256 con otra clave", opcionesRS256, crearJWT(t, "RS256", reclamos(), firmaRS256(t, otraClaveRSA)), "apirest.tokenInvalido"},
+		{"reclamos modificados", opcionesHS256, modificado, "apirest.tokenInvalido"},
+		{"algoritmo distinto a la clave", opcionesHS256, crearJWT(t, "RS256", reclamos(), firmaRS256(t, claveRSA)), "apirest.tokenInvalido"},
+		{"confusión de claves", opcionesRS256, crearJWT(t, "HS256", reclamos(), firmaHS256(publicaDER)), "apirest.tokenInvalido"},
+		{"alg none", opcionesHS256, crearJWT(t, "none", reclamos(), nil), "apirest.tokenInvalido"},
+		{"alg none con ambas claves", OpcionesJWT{ClaveHS256: claveHS256, ClaveRS256: &claveRSA.PublicKey}, crearJWT(t, "none", reclamos(), nil), "apirest.tokenInvalido"},
+		{"sin tres partes", opcionesHS256, "a.b", "apirest.tokenInvalido"},
+		{"firma mal codificada", opcionesHS256, crearJWT(t, "HS256", reclamos(), nil) + "*", "apirest.tokenInvalido"},
+
+		{"vigente", opcionesHS256, crearJWT(t, "HS256", reclamos("exp", ahora.Unix()+1, "nbf", ahora.Unix()), firmaHS256(claveHS256)), ""},
+		{"expira ahora", opcionesHS256, crearJWT(t, "HS256", reclamos("exp", ahora.Unix()), firmaHS256(claveHS256)), "apirest.tokenExpirado"},
+		{"expirado", opcionesHS256, crearJWT(t, "HS256", reclamos("exp", ahora.Unix()-60), firmaHS256(claveHS256)), "apirest.tokenExpirado"},
+		{"expirado con tolerancia", OpcionesJWT{ClaveHS256: claveHS256, Tolerancia: time.Minute},
+			crearJWT(t, "HS256", reclamos("exp", ahora.Unix()-30), firmaHS256(claveHS256)), ""},
+		{"exp no numérico", opcionesHS256, crearJWT(t, "HS256", reclamos("exp", "1600000100"), firmaHS256(claveHS256)), "apirest.tokenInvalido"},
+		{"exp nulo", opcionesHS256, crearJWT(t, "HS256", reclamos("exp", nil), firmaHS256(claveHS256)), "apirest.tokenInvalido"},
+		{"aún no válido", opcionesHS256, crearJWT(t, "HS256", reclamos("nbf", ahora.Unix()+60), firmaHS256(claveHS256)), "apirest.tokenInvalido"},
+		{"aún no válido con tolerancia", OpcionesJWT{ClaveHS256: claveHS256, Tolerancia: time.Minute},
+			crearJWT(t, "HS256", reclamos("nbf", ahora.Unix()+30), firmaHS256(claveHS256)), ""},
+		{"nbf no numérico", opcionesHS256, crearJWT(t, "HS256", reclamos("nbf", true), firmaHS256(claveHS256)), "apirest.tokenInvalido"},
+
+		{"emisor", OpcionesJWT{ClaveHS256: claveHS256, Emisor: "https://auth.ejemplo.com"},
+			crearJWT(t, "HS256", reclamos("iss", "https://auth.ejemplo.com"), firmaHS256(claveHS256)), ""},
+		{"emisor inválido", OpcionesJWT{ClaveHS256: claveHS256, Emisor: "https://auth.ejemplo.com"},
+			crearJWT(t, "HS256", reclamos("iss", "https://otro.com"), firmaHS256(claveHS256)), "apirest.tokenInvalido"},
+		{"sin emisor", OpcionesJWT{ClaveHS256: claveHS256, Emisor: "https://auth.ejemplo.com"},
+			crearJWT(t, "HS256", reclamos(), firmaHS256(claveHS256)), "apirest.tokenInvalido"},
+		{"audiencia", OpcionesJWT{ClaveHS256: claveHS256, Audiencia: "personas"},
+			crearJWT(t, "HS256", reclamos("aud", "personas"), firmaHS256(claveHS256)), ""},
+		{"audiencia en lista", OpcionesJWT{ClaveHS256: claveHS256, Audiencia: "personas"},
+			crearJWT(t, "HS256", reclamos("aud", []string{"ventas", "personas"}), firmaHS256(claveHS256)), ""},
+		{"audiencia inválida", OpcionesJWT{ClaveHS256: claveHS256, Audiencia: "personas"},
+			crearJWT(t, "HS256", reclamos("aud", []string{"ventas"}), firmaHS256(claveHS256)), "apirest.tokenInvalido"},
+	}
+	for _, p := range pruebas {
+		t.Run(p.nombre, func(t *testing.T) {
+			verificados, codigo, err := p.opciones.verificar(p.token, ahora)
+			if codigo != p.codigo || (err == nil) != (p.codigo == "") {
+				t.Fatalf("se obtuvo %q %v, se esperaba %q", codigo, err, p.codigo)
+			}
+			if p.codigo == "" && verificados["sub"] != "ana" {
+				t.Errorf("se obtuvieron los reclamos %v", verificados)
+			}
+		})
+	}
+}
+
+func TestInterceptorJWT(t *testing.T) {
+	clave := []byte("secreto-compartido")
+	r := CrearEnrutador()
+	r.Usar(InterceptorJWT(OpcionesJWT{ClaveHS256: clave, Reino: "personas"}))
+	r.GET("/personas", manejadorDeIdentidad)
+
+	token := crearJWT(t, "HS256", map[string]interface{}{"sub": "ana", "exp": time.Now().Add(time.Minute).Unix()}, firmaHS256(clave))
+	w := solicitar(r, "GET", "/personas", map[string]string{"Authorization": "bearer " + token})
+	if w.Code != 200 || !strings.Contains(w.Body.String(), `"usuario":"ana"`) || !strings.Contains(w.Body.String(), `"sub":"ana"`) {
+		t.Errorf("se obtuvo %d %s", w.Code, w.Body.String())
+	}
+
+	vencido := crearJWT(t, "HS256", map[string]interface{}{"sub": "ana", "exp": time.Now().Unix() - 1}, firmaHS256(clave))
+	pruebas := []struct {
+		autorizacion string
+		codigo       string
+		desafio      string
+	}{
+		{"", "apirest.autenticacionRequerida", `Bearer realm="personas"`},
+		{"Basic YW5hOnNlY3JldG8=", "apirest.autenticacionRequerida", `Bearer realm="personas"`},
+		{"Bearer " + vencido, "apirest.tokenExpirado", `Bearer realm="personas", error="invalid_token"`},
+		{"Bearer x.y.z", "apirest.tokenInvalido", `Bearer realm="personas", error="invalid_token"`},
+	}
+	for _, p := range pruebas {
+		w := solicitar(r, "GET", "/personas", map[string]string{"Authorization": p.autorizacion})
+		if c := decodificarCuerpoDeError(t, w); w.Code != 401 || c.Error.Codigo != p.codigo || w.Header().Get("WWW-Authenticate") != p.desafio {
+			t.Errorf("%q: se obtuvo %d %q %q", p.autorizacion, w.Code, c.Error.Codigo, w.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func TestInterceptorJWTSinClave(t *testing.T) {
+	for _, opciones := range []OpcionesJWT{{}, {ClaveHS256: []byte{}}} {
+		r := CrearEnrutador()
+		r.Usar(InterceptorJWT(opciones))
+		r.GET("/personas", manejadorDeIdentidad)
+
+		if err := r.Validar(); !ErrorEsRegistro(err, ConflictoInterceptorInvalido) {
+			t.Errorf("%+v: se esperaba un conflicto de interceptor inválido, se obtuvo %v", opciones, err)
+		}
+	}
+}
+
+func TestInterceptorJWTClaveHS256Vacia(t *testing.T) {
+	claveRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := CrearEnrutador()
+	r.Usar(InterceptorJWT(OpcionesJWT{ClaveHS256: []byte{}, ClaveRS256: &claveRSA.PublicKey}))
+	r.GET("/personas", manejadorDeIdentidad)
+
+	// un token HS256 firmado con la clave vacía no se acepta
+	token := crearJWT(t, "HS256", map[string]interface{}{"sub": "ana"}, firmaHS256([]byte{}))
+	if w := solicitar(r, "GET", "/personas", map[string]string{"Authorization": "Bearer " + token}); w.Code != 401 {
+		t.Errorf("se obtuvo %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAutenticacionEnPreflightCORS(t *testing.T) {
+	pruebas := []struct {
+		nombre      string
+		interceptor InterceptorFunc
+	}{
+		{"básica", InterceptorAutenticacionBasica("apirest", CredencialesBasicas(map[string]string{"ana": "secreto"}))},
+		{"clave de API", InterceptorClaveDeAPI("X-API-Key", map[string]string{"c1a2v3e4": "facturacion"})},
+		{"JWT", InterceptorJWT(OpcionesJWT{ClaveHS256: []byte("secreto-compartido")})},
+		{"alcances", InterceptorAlcances("personas:leer")},
+	}
+	preflight := map[string]string{"Origin": "https://a.com", "Access-Control-Request-Method": "GET"}
+	for _, p := range pruebas {
+		t.Run(p.nombre, func(t *testing.T) {
+			r := CrearEnrutador().CORSActivar()
+			r.Usar(p.interceptor)
+			r.GET("/personas", manejadorDeIdentidad)
+
+			// el navegador no envía credenciales en la verificación previa
+			w := solicitar(r, "OPTIONS", "/personas", preflight)
+			if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "*" {
+				t.Errorf("preflight: se obtuvo %d %v", w.Code, w.Header())
+			}
+			if w := solicitar(r, "GET", "/personas", map[string]string{"Origin": "https://a.com"}); w.Code != 401 {
+				t.Errorf("GET: se obtuvo %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestAutenticacionEnOPTIONSSinCORS(t *testing.T) {
+	// sin CORS, el enrutador no responde la verificación previa: la solicitud
+	// llega al endpoint OPTIONS y debe autenticarse
+	r := CrearEnrutador()
+	r.Usar(InterceptorClaveDeAPI("X-API-Key", map[string]string{"c1a2v3e4": "facturacion"}))
+	r.Manejar("OPTIONS", "/personas", manejadorDeIdentidad)
+
+	w := solicitar(r, "OPTIONS", "/personas", map[string]string{"Origin": "https://a.com", "Access-Control-Request-Method": "GET"})
+	if w.Code != 401 {
+		t.Errorf("se obtuvo %d", w.Code)
+	}
+}
+
+func TestInterceptorAlcances(t *testing.T) {
+	clave := []byte("secreto-compartido")
+	r := CrearEnrutador()
+	r.Usar(InterceptorJWT(OpcionesJWT{ClaveHS256: clave}))
+	r.DELETE("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, nil
+	}).Interceptores(InterceptorAlcances("personas:leer", "personas:borrar"))
+
+	pruebas := []struct {
+		nombre   string
+		reclamos map[string]interface{}
+		estado   int
+	}{
+		{"scope", map[string]interface{}{"scope": "personas:leer personas:borrar"}, 204},
+		{"scp", map[string]interface{}{"scp": []string{"personas:borrar", "personas:leer"}}, 204},
+		{"alcance faltante", map[string]interface{}{"scope": "personas:leer"}, 403},
+		{"alcance con otras mayúsculas", map[string]interface{}{"scope": "personas:leer PERSONAS:BORRAR"}, 403},
+		{"alcance como prefijo", map[string]interface{}{"scope": "personas:leer personas:borrar:todas"}, 403},
+		{"sin alcances", map[string]interface{}{}, 403},
+	}
+	for _, p := range pruebas {
+		t.Run(p.nombre, func(t *testing.T) {
+			token := crearJWT(t, "HS256", p.reclamos, firmaHS256(clave))
+			w := solicitar(r, "DELETE", "/personas", map[string]string{"Authorization": "Bearer " + token})
+			if w.Code != p.estado {
+				t.Fatalf("se obtuvo %d %s", w.Code, w.Body.String())
+			}
+			if p.estado == 403 {
+				c := decodificarCuerpoDeError(t, w)
+				if c.Error.Codigo != "apirest.alcanceInsuficiente" ||
+					!reflect.DeepEqual(c.Error.ValoresAdicionales, []string{"personas:leer", "personas:borrar"}) ||
+					w.Header().Get("WWW-Authenticate") != `Bearer error="insufficient_scope", scope="personas:leer personas:borrar"` {
+					t.Errorf("se obtuvo %q %v %v", c.Error.Codigo, c.Error.ValoresAdicionales, w.Header())
+				}
+			}
+		})
+	}
+}
+
+func TestInterceptorAlcancesSinIdentidad(t *testing.T) {
+	r := CrearEnrutador()
+	r.GET("/personas", manejadorDeIdentidad).Interceptores(InterceptorAlcances("personas:leer"))
+
+	w := solicitar(r, "GET", "/personas", nil)
+	if c := decodificarCuerpoDeError(t, w); w.Code != 401 || c.Error.Codigo != "apirest.autenticacionRequerida" ||
+		w.Header().Get("WWW-Authenticate") != `Bearer scope="personas:leer"` {
+		t.Errorf("se obtuvo %d %q %v", w.Code, c.Error.Codigo, w.Header())
+	}
+}
+
+func TestInterceptorAlcancesConOtraAutenticacion(t *testing.T) {
+	r := CrearEnrutador()
+	r.Usar(InterceptorClaveDeAPI("X-API-Key", map[string]string{"c1a2v3e4": "facturacion"}))
+	r.GET("/personas", manejadorDeIdentidad).Interceptores(InterceptorAlcances("personas:leer"))
+
+	// la solicitud se encuentra autenticada, pero sin alcances
+	if w := solicitar(r, "GET", "/personas", map[string]string{"X-API-Key": "c1a2v3e4"}); w.Code != 403 {
+		t.Errorf("se obtuvo %d", w.Code)
+	}
+}
@@ -0,0 +1,335 @@
+package apirest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CodificadorFunc es la función que serializa el valor recibido en el cuerpo
+// de la respuesta, según un tipo de contenido (ver RegistrarCodificador).
+// Si el tipo del valor no puede representarse con el tipo de contenido, la
+// función debe devolver un error 406 (ErrorNuevoNoAceptable), para que
+// HTTPResponderValor intente con el siguiente codificador aceptado.
+type CodificadorFunc func(w io.Writer, valor interface{}) error
+
+// codificador asocia un tipo de contenido con la función que lo serializa.
+type codificador struct {
+	tipo      string        // tipo de contenido sin parámetros ("application/json")
+	contenido HTTPContenido // tipo de contenido completo que se responde
+	funcion   CodificadorFunc
+}
+
+// codificadores almacena los codificadores registrados, en orden de registro.
+// El primer codificador es el que se utiliza cuando el requerimiento no posee
+// el campo de cabecera "Accept" (o acepta cualquier tipo de contenido).
+var codificadores = struct {
+	sync.RWMutex
+	lista []codificador
+}{
+	lista: []codificador{
+		{"application/json", HTTPContenidoApplicationJSON, codificarJSON},
+		{"application/xml", HTTPContenidoApplicationXML, codificarXML},
+		{"text/xml", HTTPContenidoTextXML, codificarXML},
+		{"text/csv", HTTPContenidoTextCSV, codificarCSV},
+		{"text/plain", HTTPContenidoTextPlain, codificarTexto},
+	},
+}
+
+// RegistrarCodificador registra la función que serializa los valores con el
+// tipo de contenido recibido, utilizada por HTTPResponderValor. Si el tipo de
+// contenido ya se encuentra registrado, se reemplaza su función.
+// Los codificadores JSON, XML (application/xml y text/xml), CSV y texto plano
+// se encuentran registrados.
+// Ejemplo:
+// 	apirest.RegistrarCodificador("application/yaml", func(w io.Writer, v interface{}) error {
+// 		return yaml.NewEncoder(w).Encode(v)
+// 	})
+func RegistrarCodificador(contenido HTTPContenido, funcion CodificadorFunc) error {
+	tipo, _, err := mime.ParseMediaType(contenido.obtenerTexto())
+	if err != nil {
+		return fmt.Errorf("el tipo de contenido %q es inválido: %v", contenido, err)
+	}
+
+	codificadores.Lock()
+	defer codificadores.Unlock()
+	for i := range codificadores.lista {
+		if codificadores.lista[i].tipo == tipo {
+			codificadores.lista[i].contenido, codificadores.lista[i].funcion = contenido, funcion
+			return nil
+		}
+	}
+	codificadores.lista = append(codificadores.lista, codificador{tipo, contenido, funcion})
+
+	return nil
+}
+
+// HTTPResponderValor serializa el valor recibido con el codificador que mejor
+// coincide con el campo de cabecera "Accept" del requerimiento (se tienen en
+// cuenta los valores de calidad "q") y realiza la respuesta HTTP.
+// Si el valor es nil, se responde 204 (sin contenido). Si el codificador
+// elegido no puede representar el tipo del valor (por ejemplo, un map en XML),
+// se intenta con el siguiente codificador aceptado. Si ningún codificador es
+// aceptado o puede representar el valor, se responde y se devuelve un error
+// 406 (No aceptable) con el código "apirest.contenidoNoAceptable".
+// Cualquier otro error del codificador (por ejemplo, un error de un método
+// MarshalJSON) se responde y se devuelve como un error 500 con el código
+// "apirest.errorDeSerializacion".
+func HTTPResponderValor(w http.ResponseWriter, r *http.Request, estadoHTTP HTTPEstado, valor interface{}) error {
+	w.Header().Add("Vary", "Accept")
+	if valor == nil || estadoHTTP == HTTPEstadoOkSinContenido {
+		w.WriteHeader(HTTPEstadoOkSinContenido.obtenerEntero())
+		return nil
+	}
+
+	var errores []string
+	for _, c := range negociarCodificadores(r.Header.Get("Accept")) {
+		var cuerpo bytes.Buffer
+		if err := c.funcion(&cuerpo, valor); err != nil {
+			if _, ok := ErrorEsNoAceptable(err); ok {
+				errores = append(errores, fmt.Sprintf("%s: %v", c.tipo, err))
+				continue
+			}
+
+			errSerializacion := ErrorEnvolverInternoDeServidor(err, "No es posible serializar la respuesta").
+				AsignarCodigo("apirest.errorDeSerializacion").
+				AsignarMensajeTecnico("%s: %v", c.tipo, err)
+			responderErrorAPIREST(w, errSerializacion, ObtenerIDDeSolicitud(r))
+
+			return errSerializacion
+		}
+
+		w.Header().Set("Content-Type", c.contenido.obtenerTexto())
+		w.WriteHeader(estadoHTTP.obtenerEntero())
+		_, err := w.Write(cuerpo.Bytes())
+
+		return err
+	}
+
+	err := ErrorNuevoNoAceptable("No es posible responder ninguno de los tipos de contenido aceptados").
+		AsignarCodigo("apirest.contenidoNoAceptable").
+		AsignarValoresAdicionales(tiposDeCodificadores()...)
+	if len(errores) > 0 {
+		err.AsignarMensajeTecnico("%s", strings.Join(errores, "; "))
+	}
+	responderErrorAPIREST(w, err, ObtenerIDDeSolicitud(r))
+
+	return err
+}
+
+// rangoDeMedios es un elemento del campo de cabecera "Accept".
+type rangoDeMedios struct {
+	tipo, subtipo string
+	calidad       float64
+}
+
+// coincide determina si el rango coincide con el tipo de contenido recibido y
+// devuelve su especificidad (0 para "*/*", 1 para "tipo/*" y 2 para
+// "tipo/subtipo").
+func (o rangoDeMedios) coincide(tipo string) (int, bool) {
+	partes := strings.SplitN(tipo, "/", 2)
+	switch {
+	case o.tipo == "*" && o.subtipo == "*":
+		return 0, true
+	case o.tipo == partes[0] && o.subtipo == "*":
+		return 1, true
+	case len(partes) == 2 && o.tipo == partes[0] && o.subtipo == partes[1]:
+		return 2, true
+	}
+
+	return 0, false
+}
+
+// interpretarAccept devuelve los rangos de medios del campo de cabecera
+// "Accept". Los rangos inválidos se ignoran.
+func interpretarAccept(accept string) []rangoDeMedios {
+	var rangos []rangoDeMedios
+	for _, texto := range strings.Split(accept, ",") {
+		tipo, parametros, err := mime.ParseMediaType(strings.TrimSpace(texto))
+		if err != nil {
+			continue
+		}
+		partes := strings.SplitN(tipo, "/", 2)
+		if len(partes) != 2 {
+			continue
+		}
+
+		rango := rangoDeMedios{tipo: partes[0], subtipo: partes[1], calidad: 1}
+		if q, ok := parametros["q"]; ok {
+			calidad, err := strconv.ParseFloat(q, 64)
+			if err != nil || calidad < 0 || calidad > 1 {
+				continue
+			}
+			rango.calidad = calidad
+		}
+		rangos = append(rangos, rango)
+	}
+
+	return rangos
+}
+
+// negociarCodificadores devuelve los codificadores registrados aceptados por
+// el campo de cabecera "Accept", de mayor a menor calidad. La calidad de cada
+// codificador es la del rango más específico que lo incluye (los codificadores
+// con calidad 0 no son aceptados); ante calidades iguales se mantiene el orden
+// de registro. Sin campo "Accept", se devuelven todos los codificadores
+// registrados.
+func negociarCodificadores(accept string) []codificador {
+	codificadores.RLock()
+	defer codificadores.RUnlock()
+
+	if strings.TrimSpace(accept) == "" {
+		return append([]codificador(nil), codificadores.lista...)
+	}
+
+	rangos := interpretarAccept(accept)
+	type aceptado struct {
+		codificador
+		calidad float64
+	}
+	var aceptados []aceptado
+	for _, c := range codificadores.lista {
+		calidad, especificidad := 0.0, -1
+		for _, rango := range rangos {
+			if e, ok := rango.coincide(c.tipo); ok && e > especificidad {
+				calidad, especificidad = rango.calidad, e
+			}
+		}
+		if calidad > 0 {
+			aceptados = append(aceptados, aceptado{c, calidad})
+		}
+	}
+	sort.SliceStable(aceptados, func(i, j int) bool {
+		return aceptados[i].calidad > aceptados[j].calidad
+	})
+
+	lista := make([]codificador, len(aceptados))
+	for i := range aceptados {
+		lista[i] = aceptados[i].codificador
+	}
+
+	return lista
+}
+
+// tiposDeCodificadores devuelve los tipos de contenido registrados.
+func tiposDeCodificadores() []string {
+	codificadores.RLock()
+	defer codificadores.RUnlock()
+
+	var tipos []string
+	for _, c := range codificadores.lista {
+		tipos = append(tipos, c.tipo)
+	}
+
+	return tipos
+}
+
+// -----------------------------------------------------------------------------
+// Codificadores incorporados.
+
+func codificarJSON(w io.Writer, valor interface{}) error {
+	cuerpo, err := json.Marshal(valor)
+	var errTipo *json.UnsupportedTypeError
+	if errors.As(err, &errTipo) {
+		return ErrorEnvolverNoAceptable(err, "%v", err)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(cuerpo)
+
+	return err
+}
+
+func codificarXML(w io.Writer, valor interface{}) error {
+	err := xml.NewEncoder(w).Encode(valor)
+	var errTipo *xml.UnsupportedTypeError
+	if errors.As(err, &errTipo) {
+		return ErrorEnvolverNoAceptable(err, "%v", err)
+	}
+
+	return err
+}
+
+// codificarTexto serializa textos, números, valores lógicos y valores que
+// implementan fmt.Stringer o error. Los demás tipos (estructuras, mapas,
+// listas, ...) no se serializan, para no responder su representación en Go.
+func codificarTexto(w io.Writer, valor interface{}) error {
+	switch v := valor.(type) {
+	case []byte:
+		_, err := w.Write(v)
+		return err
+	case fmt.Stringer, error:
+	default:
+		tipo := reflect.TypeOf(valor).Kind()
+		if tipo != reflect.String && (tipo < reflect.Bool || tipo > reflect.Complex128) {
+			return ErrorNuevoNoAceptable("el tipo %T no puede serializarse como texto", valor)
+		}
+	}
+
+	_, err := fmt.Fprint(w, valor)
+	return err
+}
+
+// codificarCSV serializa listas de registros ([][]string) y listas de
+// estructuras (o punteros a estructuras). En las estructuras, la primera fila
+// contiene los nombres de los campos (el nombre de la etiqueta "json", si
+// existe) y se omiten los campos no exportados o con la etiqueta "json:\"-\"".
+func codificarCSV(w io.Writer, valor interface{}) error {
+	escritor := csv.NewWriter(w)
+	if registros, ok := valor.([][]string); ok {
+		return escritor.WriteAll(registros)
+	}
+
+	lista := reflect.ValueOf(valor)
+	if lista.Kind() != reflect.Slice && lista.Kind() != reflect.Array {
+		return ErrorNuevoNoAceptable("el tipo %T no puede serializarse como CSV", valor)
+	}
+	tipo := lista.Type().Elem()
+	if tipo.Kind() == reflect.Ptr {
+		tipo = tipo.Elem()
+	}
+	if tipo.Kind() != reflect.Struct {
+		return ErrorNuevoNoAceptable("el tipo %T no puede serializarse como CSV", valor)
+	}
+
+	var campos []int
+	var encabezado []string
+	for i := 0; i < tipo.NumField(); i++ {
+		campo := tipo.Field(i)
+		nombre := strings.Split(campo.Tag.Get("json"), ",")[0]
+		if campo.PkgPath != "" || nombre == "-" {
+			continue
+		}
+		if nombre == "" {
+			nombre = campo.Name
+		}
+		campos, encabezado = append(campos, i), append(encabezado, nombre)
+	}
+
+	registros := [][]string{encabezado}
+	for i := 0; i < lista.Len(); i++ {
+		elemento := reflect.Indirect(lista.Index(i))
+		if !elemento.IsValid() {
+			continue
+		}
+		registro := make([]string, len(campos))
+		for j, campo := range campos {
+			registro[j] = fmt.Sprint(elemento.Field(campo).Interface())
+		}
+		registros = append(registros, registro)
+	}
+
+	return escritor.WriteAll(registros)
+}
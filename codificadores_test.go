@@ -0,0 +1,187 @@
+package apirest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNegociarCodificadores(t *testing.T) {
+	pruebas := []struct {
+		accept    string
+		esperados []string
+	}{
+		{"", []string{"application/json", "application/xml", "text/xml", "text/csv", "text/plain"}},
+		{"application/xml", []string{"application/xml"}},
+		{"text/xml", []string{"text/xml"}},
+		{"APPLICATION/JSON", []string{"application/json"}},
+		{"text/csv;q=0.5, application/xml;q=0.9", []string{"application/xml", "text/csv"}},
+		{"*/*", []string{"application/json", "application/xml", "text/xml", "text/csv", "text/plain"}},
+		{"text/*", []string{"text/xml", "text/csv", "text/plain"}},
+		{"text/*;q=0.5, text/plain", []string{"text/plain", "text/xml", "text/csv"}},
+		{"*/*;q=0.1, application/json;q=0, text/xml;q=0", []string{"application/xml", "text/csv", "text/plain"}},
+		{"application/json;q=0", nil},
+		{"application/json;q=abc, image/png", nil},
+		{"json", nil},
+	}
+	for _, p := range pruebas {
+		var tipos []string
+		for _, c := range negociarCodificadores(p.accept) {
+			tipos = append(tipos, c.tipo)
+		}
+		if !reflect.DeepEqual(tipos, p.esperados) {
+			t.Errorf("%q: se obtuvo %v, se esperaba %v", p.accept, tipos, p.esperados)
+		}
+	}
+}
+
+func TestHTTPResponderValor(t *testing.T) {
+	type persona struct {
+		Nombre string `json:"nombre" xml:"nombre"`
+		Edad   int    `json:"edad" xml:"edad"`
+	}
+
+	pruebas := []struct {
+		nombre    string
+		valor     interface{}
+		accept    string
+		estado    int
+		contenido HTTPContenido
+		cuerpo    string
+	}{
+		{"JSON por defecto", persona{"Ana", 30}, "", 200, HTTPContenidoApplicationJSON, `{"nombre":"Ana","edad":30}`},
+		{"XML", persona{"Ana", 30}, "application/xml", 200, HTTPContenidoApplicationXML, `<persona><nombre>Ana</nombre><edad>30</edad></persona>`},
+		{"text/xml", persona{"Ana", 30}, "text/xml", 200, HTTPContenidoTextXML, `<persona><nombre>Ana</nombre><edad>30</edad></persona>`},
+		{"CSV", []*persona{{"Ana", 30}, nil, {"Juan", 40}}, "text/csv", 200, HTTPContenidoTextCSV, "nombre,edad\nAna,30\nJuan,40\n"},
+		{"texto", 42, "text/plain", 200, HTTPContenidoTextPlain, "42"},
+		{"map en XML con otro tipo aceptado", map[string]int{"total": 1}, "application/xml, application/json;q=0.5", 200, HTTPContenidoApplicationJSON, `{"total":1}`},
+		{"map en CSV con cualquier tipo aceptado", map[string]int{"total": 1}, "text/csv, */*;q=0.1", 200, HTTPContenidoApplicationJSON, `{"total":1}`},
+	}
+	for _, p := range pruebas {
+		t.Run(p.nombre, func(t *testing.T) {
+			r := CrearEnrutador()
+			r.GET("/valor", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+				return p.valor, nil
+			})
+
+			w := solicitar(r, "GET", "/valor", map[string]string{"Accept": p.accept})
+			if w.Code != p.estado || w.Header().Get("Content-Type") != p.contenido.obtenerTexto() || w.Body.String() != p.cuerpo {
+				t.Errorf("se obtuvo %d %q %q", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+			}
+			if !contieneTexto(w.Header()["Vary"], "Accept") {
+				t.Errorf("la cabecera Vary no incluye Accept: %v", w.Header()["Vary"])
+			}
+		})
+	}
+}
+
+func TestHTTPResponderValorNoAceptable(t *testing.T) {
+	pruebas := []struct {
+		nombre         string
+		valor          interface{}
+		accept         string
+		mensajeTecnico string
+	}{
+		{"tipo no registrado", "valor", "image/png", ""},
+		{"calidad 0", "valor", "application/json;q=0", ""},
+		{"map en XML", map[string]int{"total": 1}, "application/xml", "application/xml: xml: unsupported type: map[string]int"},
+		{"map en XML y CSV", map[string]int{"total": 1}, "text/csv;q=0.5, text/xml", "text/xml: xml: unsupported type: map[string]int; text/csv: "},
+		{"estructura en texto", struct{ Total int }{1}, "text/plain", "text/plain: el tipo struct { Total int } no puede serializarse como texto"},
+		{"canal en JSON", make(chan int), "application/json", "application/json: json: unsupported type: chan int"},
+	}
+	for _, p := range pruebas {
+		t.Run(p.nombre, func(t *testing.T) {
+			r := CrearEnrutador()
+			var errRespondido error
+			r.GET("/valor", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+				errRespondido = HTTPResponderValor(w, r, HTTPEstadoOk, p.valor)
+				return nil, nil
+			})
+
+			w := solicitar(r, "GET", "/valor", map[string]string{"Accept": p.accept})
+			c := decodificarCuerpoDeError(t, w)
+			if w.Code != http.StatusNotAcceptable || c.Error.Codigo != "apirest.contenidoNoAceptable" ||
+				!reflect.DeepEqual(c.Error.ValoresAdicionales, tiposDeCodificadores()) {
+				t.Errorf("se obtuvo %d %s", w.Code, w.Body.String())
+			}
+
+			errAPIREST, ok := ErrorEsNoAceptable(errRespondido)
+			if !ok || !strings.HasPrefix(errAPIREST.ObtenerMensajeTecnico(), p.mensajeTecnico) {
+				t.Errorf("se devolvió %v (%q)", errRespondido, errAPIREST.ObtenerMensajeTecnico())
+			}
+		})
+	}
+}
+
+// valorConErrorJSON es un valor cuya serialización JSON falla.
+type valorConErrorJSON struct{}
+
+func (valorConErrorJSON) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("sin conexión a la base de datos")
+}
+
+func TestHTTPResponderValorErrorDeSerializacion(t *testing.T) {
+	r := CrearEnrutador()
+	var errRespondido error
+	r.GET("/valor", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		errRespondido = HTTPResponderValor(w, r, HTTPEstadoOk, valorConErrorJSON{})
+		return nil, nil
+	})
+
+	// el error no es un tipo no admitido: no se intenta con los codificadores
+	// siguientes (XML, texto plano, ...)
+	w := solicitar(r, "GET", "/valor", nil)
+	if c := decodificarCuerpoDeError(t, w); w.Code != http.StatusInternalServerError || c.Error.Codigo != "apirest.errorDeSerializacion" {
+		t.Errorf("se obtuvo %d %s", w.Code, w.Body.String())
+	}
+	errAPIREST, ok := ErrorEsInternoDeServidor(errRespondido)
+	if !ok || !strings.HasPrefix(errAPIREST.ObtenerMensajeTecnico(), "application/json: json: error calling MarshalJSON") {
+		t.Errorf("se devolvió %v (%q)", errRespondido, errAPIREST.ObtenerMensajeTecnico())
+	}
+}
+
+func TestRegistrarCodificador(t *testing.T) {
+	anteriores := append([]codificador(nil), codificadores.lista...)
+	defer func() { codificadores.lista = anteriores }()
+
+	if err := RegistrarCodificador("application/x-prueba; charset=utf-8", func(w io.Writer, valor interface{}) error {
+		_, err := fmt.Fprintf(w, "prueba:%v", valor)
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegistrarCodificador("tipo inválido", codificarTexto); err == nil {
+		t.Errorf("se registró un tipo de contenido inválido")
+	}
+	// el reemplazo de un tipo registrado mantiene su posición
+	if err := RegistrarCodificador("text/plain; charset=iso-8859-1", codificarTexto); err != nil {
+		t.Fatal(err)
+	}
+
+	r := CrearEnrutador()
+	r.GET("/valor", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return 42, nil
+	})
+
+	w := solicitar(r, "GET", "/valor", map[string]string{"Accept": "application/x-prueba"})
+	if w.Header().Get("Content-Type") != "application/x-prueba; charset=utf-8" || w.Body.String() != "prueba:42" {
+		t.Errorf("se obtuvo %q %q", w.Header().Get("Content-Type"), w.Body.String())
+	}
+	w = solicitar(r, "GET", "/valor", map[string]string{"Accept": "text/plain"})
+	if w.Header().Get("Content-Type") != "text/plain; charset=iso-8859-1" || len(codificadores.lista) != len(anteriores)+1 {
+		t.Errorf("se obtuvo %q con %d codificadores", w.Header().Get("Content-Type"), len(codificadores.lista))
+	}
+}
+
+func TestCodificarCSVTiposNoAdmitidos(t *testing.T) {
+	for _, valor := range []interface{}{"texto", []int{1, 2}, map[string]int{}} {
+		if err := codificarCSV(ioutil.Discard, valor); err == nil {
+			t.Errorf("%T: se esperaba un error", valor)
+		}
+	}
+}
@@ -0,0 +1,472 @@
+package apirest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parametrosDeConsulta permite obtener los parámetros de consulta (query
+// string) de la solicitud con su tipo. Ver ObtenerParametrosDeConsulta.
+type parametrosDeConsulta struct {
+	valores url.Values
+}
+
+// ObtenerParametrosDeConsulta devuelve los parámetros de consulta de la
+// solicitud recibida. Cuando el parámetro no existe (o es vacío), se devuelve
+// el valor por defecto; cuando su valor no es válido, se devuelve un error de
+// validación (400) con el parámetro como campo con error.
+// Ejemplo:
+// 	consulta := apirest.ObtenerParametrosDeConsulta(r)
+// 	pagina, err := consulta.Entero("pagina", 1)
+// 	if err != nil {
+// 		return nil, err
+// 	}
+func ObtenerParametrosDeConsulta(r *http.Request) parametrosDeConsulta {
+	return parametrosDeConsulta{valores: r.URL.Query()}
+}
+
+// Existe determina si el parámetro de consulta se encuentra en la solicitud.
+func (o parametrosDeConsulta) Existe(nombre string) bool {
+	_, ok := o.valores[nombre]
+	return ok
+}
+
+// Texto devuelve el valor del parámetro de consulta.
+func (o parametrosDeConsulta) Texto(nombre, porDefecto string) string {
+	if valor := o.valores.Get(nombre); valor != "" {
+		return valor
+	}
+
+	return porDefecto
+}
+
+// Lista devuelve todos los valores del parámetro de consulta, cuando se
+// repite en la solicitud ("?estado=activo&estado=baja").
+func (o parametrosDeConsulta) Lista(nombre string) []string {
+	return o.valores[nombre]
+}
+
+// Entero devuelve el valor del parámetro de consulta convertido a entero.
+func (o parametrosDeConsulta) Entero(nombre string, porDefecto int) (int, error) {
+	valor := o.valores.Get(nombre)
+	if valor == "" {
+		return porDefecto, nil
+	}
+
+	n, err := strconv.Atoi(valor)
+	if err != nil {
+		return porDefecto, errorDeParametroDeConsulta(nombre, "tipoInvalido", "El parámetro de consulta %v no es un número entero", nombre).
+			AsignarMensajeTecnico("%v", err)
+	}
+
+	return n, nil
+}
+
+// Decimal devuelve el valor del parámetro de consulta convertido a número
+// decimal.
+func (o parametrosDeConsulta) Decimal(nombre string, porDefecto float64) (float64, error) {
+	valor := o.valores.Get(nombre)
+	if valor == "" {
+		return porDefecto, nil
+	}
+
+	n, err := strconv.ParseFloat(valor, 64)
+	if err != nil {
+		return porDefecto, errorDeParametroDeConsulta(nombre, "tipoInvalido", "El parámetro de consulta %v no es un número", nombre).
+			AsignarMensajeTecnico("%v", err)
+	}
+
+	return n, nil
+}
+
+// Booleano devuelve el valor del parámetro de consulta convertido a booleano
+// ("true", "false", "1", "0", ...).
+func (o parametrosDeConsulta) Booleano(nombre string, porDefecto bool) (bool, error) {
+	valor := o.valores.Get(nombre)
+	if valor == "" {
+		return porDefecto, nil
+	}
+
+	b, err := strconv.ParseBool(valor)
+	if err != nil {
+		return porDefecto, errorDeParametroDeConsulta(nombre, "tipoInvalido", "El parámetro de consulta %v no es un valor booleano", nombre).
+			AsignarMensajeTecnico("%v", err)
+	}
+
+	return b, nil
+}
+
+// Fecha devuelve el valor del parámetro de consulta convertido a fecha
+// (formatos: "aaaa-mm-dd" o RFC 3339).
+func (o parametrosDeConsulta) Fecha(nombre string, porDefecto time.Time) (time.Time, error) {
+	valor := o.valores.Get(nombre)
+	if valor == "" {
+		return porDefecto, nil
+	}
+
+	fecha, err := convertirFecha(valor, "")
+	if err != nil {
+		return porDefecto, errorDeParametroDeConsulta(nombre, "tipoInvalido", "El parámetro de consulta %v no es una fecha", nombre).
+			AsignarMensajeTecnico("%v", err)
+	}
+
+	return fecha, nil
+}
+
+// errorDeParametroDeConsulta crea un error de validación (400) con el
+// parámetro de consulta como campo con error.
+func errorDeParametroDeConsulta(nombre, codigo, formato string, args ...interface{}) *errorAPIREST {
+	err := ErrorNuevoValidacion("Los parámetros de consulta no son válidos")
+	err.asignarRastro(1)
+
+	return err.AgregarErrorDeCampo(nombre, codigo, fmt.Sprintf(formato, args...), nil)
+}
+
+// convertirFecha convierte el texto recibido a fecha con el formato recibido.
+// Sin formato, se aceptan los formatos "aaaa-mm-dd" y RFC 3339.
+func convertirFecha(texto, formato string) (time.Time, error) {
+	if formato != "" {
+		return time.Parse(formato, texto)
+	}
+
+	fecha, err := time.Parse(formatoFecha, texto)
+	if err != nil {
+		return time.Parse(time.RFC3339, texto)
+	}
+
+	return fecha, nil
+}
+
+// -----------------------------------------------------------------------------
+// Decodificar los parámetros de consulta en una estructura.
+
+// etiquetaDeConsulta es la interpretación de la etiqueta "consulta" de un
+// campo de estructura:
+// 	`consulta:"nombre,requerido,defecto=1,min=1,max=100,opciones=a|b,formato=2006-01"`
+type etiquetaDeConsulta struct {
+	nombre    string   // nombre del parámetro de consulta
+	requerido bool     // el parámetro debe existir en la solicitud
+	defecto   string   // valor por defecto cuando el parámetro no existe
+	minimo    *float64 // valor mínimo (números), longitud mínima (textos) o cantidad mínima de valores (listas)
+	maximo    *float64 // valor máximo (números), longitud máxima (textos) o cantidad máxima de valores (listas)
+	opciones  []string // valores admitidos
+	formato   string   // formato de las fechas (time.Time)
+}
+
+// interpretarEtiquetaDeConsulta interpreta la etiqueta "consulta" del campo.
+func interpretarEtiquetaDeConsulta(etiqueta string) (etiquetaDeConsulta, error) {
+	partes := strings.Split(etiqueta, ",")
+	e := etiquetaDeConsulta{nombre: partes[0]}
+	for _, parte := range partes[1:] {
+		clave, valor := parte, ""
+		if pos := strings.Index(parte, "="); pos >= 0 {
+			clave, valor = parte[:pos], parte[pos+1:]
+		}
+
+		switch clave {
+		case "requerido":
+			e.requerido = true
+		case "defecto":
+			e.defecto = valor
+		case "min", "max":
+			n, err := strconv.ParseFloat(valor, 64)
+			if err != nil {
+				return e, fmt.Errorf("el valor de %v no es un número: %v", clave, valor)
+			}
+			if clave == "min" {
+				e.minimo = &n
+			} else {
+				e.maximo = &n
+			}
+		case "opciones":
+			e.opciones = strings.Split(valor, "|")
+		case "formato":
+			e.formato = valor
+		default:
+			return e, fmt.Errorf("la opción %v es desconocida", clave)
+		}
+	}
+
+	return e, nil
+}
+
+// HTTPDecodificarConsulta decodifica los parámetros de consulta de la
+// solicitud en los campos de la estructura destino (un puntero) que poseen la
+// etiqueta "consulta". Los campos pueden ser textos, números, booleanos,
+// fechas (time.Time), duraciones (time.Duration), punteros a estos tipos (nil
+// si el parámetro no existe) y listas de estos tipos (el parámetro se repite
+// en la solicitud). La etiqueta admite las siguientes opciones:
+// 	requerido:      el parámetro debe existir.
+// 	defecto=valor:  valor por defecto cuando el parámetro no existe.
+// 	min=n, max=n:   valor mínimo y máximo (números), longitud mínima y
+// 	                máxima (textos) o cantidad de valores (listas).
+// 	opciones=a|b:   valores admitidos.
+// 	formato=layout: formato de las fechas (por defecto "aaaa-mm-dd" o RFC 3339).
+// Los parámetros inválidos se devuelven como un único error de validación
+// (400), con un error de campo por cada parámetro. Un destino, una etiqueta o
+// un tipo de campo no admitido se devuelven como error interno del servidor
+// (500).
+// Ejemplo:
+// 	var filtro struct {
+// 		Pagina  int       `consulta:"pagina,defecto=1,min=1"`
+// 		Estados []string  `consulta:"estado,opciones=activo|baja"`
+// 		Desde   time.Time `consulta:"desde"`
+// 	}
+// 	if err := apirest.HTTPDecodificarConsulta(r, &filtro); err != nil {
+// 		return nil, err
+// 	}
+func HTTPDecodificarConsulta(r *http.Request, destino interface{}) error {
+	estructura := reflect.ValueOf(destino)
+	if estructura.Kind() != reflect.Ptr || estructura.Elem().Kind() != reflect.Struct {
+		return ErrorNuevoInternoDeServidor("Error interno del servidor").
+			AsignarCodigo("apirest.errorInternoDeServidor").
+			AsignarMensajeTecnico("el destino de los parámetros de consulta debe ser un puntero a una estructura: %T", destino)
+	}
+	estructura = estructura.Elem()
+
+	valores := r.URL.Query()
+	errValidacion := ErrorNuevoValidacion("Los parámetros de consulta no son válidos")
+	errValidacion.asignarRastro(1)
+	for i := 0; i < estructura.NumField(); i++ {
+		campo := estructura.Type().Field(i)
+		texto, ok := campo.Tag.Lookup("consulta")
+		if !ok || texto == "-" || campo.PkgPath != "" {
+			continue
+		}
+		etiqueta, err := interpretarEtiquetaDeConsulta(texto)
+		if err != nil {
+			return ErrorNuevoInternoDeServidor("Error interno del servidor").
+				AsignarCodigo("apirest.errorInternoDeServidor").
+				AsignarMensajeTecnico("la etiqueta consulta del campo %v es inválida: %v", campo.Name, err)
+		}
+		// el tipo del campo es un error del programa, no de la solicitud
+		if !tipoDeConsultaAdmitido(campo.Type) {
+			return ErrorNuevoInternoDeServidor("Error interno del servidor").
+				AsignarCodigo("apirest.errorInternoDeServidor").
+				AsignarMensajeTecnico("el tipo %v del campo %v no es admitido en los parámetros de consulta", campo.Type, campo.Name)
+		}
+		if etiqueta.nombre == "" {
+			etiqueta.nombre = campo.Name
+		}
+
+		lista := valores[etiqueta.nombre]
+		if len(lista) == 0 || (len(lista) == 1 && lista[0] == "") {
+			if etiqueta.requerido {
+				errValidacion.AgregarErrorDeCampo(etiqueta.nombre, "requerido",
+					fmt.Sprintf("El parámetro de consulta %v es requerido", etiqueta.nombre), nil)
+				continue
+			}
+			if etiqueta.defecto == "" {
+				continue
+			}
+			lista = []string{etiqueta.defecto}
+		}
+
+		if errCampo := asignarParametroDeConsulta(estructura.Field(i), lista, etiqueta); errCampo != nil {
+			errValidacion.erroresDeCampos = append(errValidacion.erroresDeCampos, *errCampo)
+		}
+	}
+
+	if len(errValidacion.erroresDeCampos) > 0 {
+		return errValidacion
+	}
+
+	return nil
+}
+
+// asignarParametroDeConsulta convierte y valida los valores del parámetro de
+// consulta y los asigna al campo. Devuelve el error del campo, si existe.
+func asignarParametroDeConsulta(campo reflect.Value, lista []string, etiqueta etiquetaDeConsulta) *errorDeCampo {
+	if campo.Kind() == reflect.Slice {
+		if errCampo := validarLongitudDeConsulta(len(lista), etiqueta); errCampo != nil {
+			return errCampo
+		}
+		// las opciones "min" y "max" se aplican a la cantidad de valores
+		etiquetaDeElementos := etiqueta
+		etiquetaDeElementos.minimo, etiquetaDeElementos.maximo = nil, nil
+
+		elementos := reflect.MakeSlice(campo.Type(), len(lista), len(lista))
+		for i, texto := range lista {
+			if errCampo := convertirParametroDeConsulta(elementos.Index(i), texto, etiquetaDeElementos); errCampo != nil {
+				return errCampo
+			}
+		}
+		campo.Set(elementos)
+		return nil
+	}
+
+	if campo.Kind() == reflect.Ptr {
+		valor := reflect.New(campo.Type().Elem())
+		if errCampo := convertirParametroDeConsulta(valor.Elem(), lista[0], etiqueta); errCampo != nil {
+			return errCampo
+		}
+		campo.Set(valor)
+		return nil
+	}
+
+	return convertirParametroDeConsulta(campo, lista[0], etiqueta)
+}
+
+// tipoDeConsultaAdmitido determina si el tipo del campo puede decodificarse
+// desde los parámetros de consulta (ver HTTPDecodificarConsulta).
+func tipoDeConsultaAdmitido(tipo reflect.Type) bool {
+	if tipo.Kind() == reflect.Slice || tipo.Kind() == reflect.Ptr {
+		tipo = tipo.Elem()
+	}
+	if tipo == reflect.TypeOf(time.Time{}) {
+		return true
+	}
+
+	switch tipo.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
+
+// convertirParametroDeConsulta convierte el texto recibido al tipo del campo,
+// validando las opciones de la etiqueta. El tipo del campo es uno de los tipos
+// admitidos (ver tipoDeConsultaAdmitido).
+func convertirParametroDeConsulta(campo reflect.Value, texto string, etiqueta etiquetaDeConsulta) *errorDeCampo {
+	errTipo := func(tipo string) *errorDeCampo {
+		return &errorDeCampo{
+			Campo:      etiqueta.nombre,
+			Codigo:     "tipoInvalido",
+			Mensaje:    fmt.Sprintf("El parámetro de consulta %v no es %v", etiqueta.nombre, tipo),
+			Parametros: map[string]interface{}{"valor": texto},
+		}
+	}
+
+	if len(etiqueta.opciones) > 0 && !esOpcionDeConsulta(etiqueta.opciones, texto) {
+		return &errorDeCampo{
+			Campo:      etiqueta.nombre,
+			Codigo:     "opcionInvalida",
+			Mensaje:    fmt.Sprintf("El parámetro de consulta %v debe ser uno de: %v", etiqueta.nombre, strings.Join(etiqueta.opciones, ", ")),
+			Parametros: map[string]interface{}{"opciones": etiqueta.opciones},
+		}
+	}
+
+	switch campo.Interface().(type) {
+	case time.Time:
+		fecha, err := convertirFecha(texto, etiqueta.formato)
+		if err != nil {
+			return errTipo("una fecha")
+		}
+		campo.Set(reflect.ValueOf(fecha))
+		return nil
+
+	case time.Duration:
+		duracion, err := time.ParseDuration(texto)
+		if err != nil {
+			return errTipo("una duración")
+		}
+		campo.SetInt(int64(duracion))
+		return nil
+	}
+
+	switch campo.Kind() {
+	case reflect.String:
+		campo.SetString(texto)
+		return validarLongitudDeConsulta(len([]rune(texto)), etiqueta)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(texto)
+		if err != nil {
+			return errTipo("un valor booleano")
+		}
+		campo.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(texto, 10, campo.Type().Bits())
+		if err != nil {
+			return errTipo("un número entero")
+		}
+		campo.SetInt(n)
+		return validarRangoDeConsulta(float64(n), etiqueta)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(texto, 10, campo.Type().Bits())
+		if err != nil {
+			return errTipo("un número entero positivo")
+		}
+		campo.SetUint(n)
+		return validarRangoDeConsulta(float64(n), etiqueta)
+
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(texto, campo.Type().Bits())
+		if err != nil {
+			return errTipo("un número")
+		}
+		campo.SetFloat(n)
+		return validarRangoDeConsulta(n, etiqueta)
+	}
+
+	return nil
+}
+
+// esOpcionDeConsulta determina si el texto es uno de los valores admitidos
+// (distingue mayúsculas y minúsculas).
+func esOpcionDeConsulta(opciones []string, texto string) bool {
+	for _, opcion := range opciones {
+		if opcion == texto {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validarRangoDeConsulta valida el valor numérico del parámetro de consulta
+// con las opciones "min" y "max" de la etiqueta.
+func validarRangoDeConsulta(n float64, etiqueta etiquetaDeConsulta) *errorDeCampo {
+	if etiqueta.minimo != nil && n < *etiqueta.minimo {
+		return &errorDeCampo{
+			Campo:      etiqueta.nombre,
+			Codigo:     "minimo",
+			Mensaje:    fmt.Sprintf("El parámetro de consulta %v debe ser mayor o igual a %v", etiqueta.nombre, *etiqueta.minimo),
+			Parametros: map[string]interface{}{"minimo": *etiqueta.minimo},
+		}
+	}
+	if etiqueta.maximo != nil && n > *etiqueta.maximo {
+		return &errorDeCampo{
+			Campo:      etiqueta.nombre,
+			Codigo:     "maximo",
+			Mensaje:    fmt.Sprintf("El parámetro de consulta %v debe ser menor o igual a %v", etiqueta.nombre, *etiqueta.maximo),
+			Parametros: map[string]interface{}{"maximo": *etiqueta.maximo},
+		}
+	}
+
+	return nil
+}
+
+// validarLongitudDeConsulta valida la longitud del texto o la cantidad de
+// valores del parámetro de consulta con las opciones "min" y "max".
+func validarLongitudDeConsulta(longitud int, etiqueta etiquetaDeConsulta) *errorDeCampo {
+	if etiqueta.minimo != nil && float64(longitud) < *etiqueta.minimo {
+		return &errorDeCampo{
+			Campo:      etiqueta.nombre,
+			Codigo:     "longitudMinima",
+			Mensaje:    fmt.Sprintf("El parámetro de consulta %v debe poseer una longitud mayor o igual a %v", etiqueta.nombre, *etiqueta.minimo),
+			Parametros: map[string]interface{}{"minimo": *etiqueta.minimo},
+		}
+	}
+	if etiqueta.maximo != nil && float64(longitud) > *etiqueta.maximo {
+		return &errorDeCampo{
+			Campo:      etiqueta.nombre,
+			Codigo:     "longitudMaxima",
+			Mensaje:    fmt.Sprintf("El parámetro de consulta %v debe poseer una longitud menor o igual a %v", etiqueta.nombre, *etiqueta.maximo),
+			Parametros: map[string]interface{}{"maximo": *etiqueta.maximo},
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package apirest
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestObtenerParametrosDeConsulta(t *testing.T) {
+	r := httptest.NewRequest("GET", "/personas?pagina=2&precio=1.5&activo=true&desde=2020-01-02&estado=activo&estado=baja&vacio=", nil)
+	consulta := ObtenerParametrosDeConsulta(r)
+
+	if !consulta.Existe("vacio") || consulta.Existe("otro") {
+		t.Errorf("Existe no informa los parámetros de la solicitud")
+	}
+	if texto := consulta.Texto("vacio", "defecto"); texto != "defecto" {
+		t.Errorf("Texto devolvió %q", texto)
+	}
+	if lista := consulta.Lista("estado"); !reflect.DeepEqual(lista, []string{"activo", "baja"}) {
+		t.Errorf("Lista devolvió %v", lista)
+	}
+	if n, err := consulta.Entero("pagina", 1); n != 2 || err != nil {
+		t.Errorf("Entero devolvió %v %v", n, err)
+	}
+	if n, err := consulta.Entero("otro", 1); n != 1 || err != nil {
+		t.Errorf("Entero devolvió %v %v", n, err)
+	}
+	if n, err := consulta.Decimal("precio", 0); n != 1.5 || err != nil {
+		t.Errorf("Decimal devolvió %v %v", n, err)
+	}
+	if b, err := consulta.Booleano("activo", false); !b || err != nil {
+		t.Errorf("Booleano devolvió %v %v", b, err)
+	}
+	if fecha, err := consulta.Fecha("desde", time.Time{}); !fecha.Equal(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)) || err != nil {
+		t.Errorf("Fecha devolvió %v %v", fecha, err)
+	}
+
+	// un valor inválido devuelve el valor por defecto y un error de validación
+	n, err := consulta.Entero("estado", 7)
+	errValidacion, ok := ErrorEsValidacion(err)
+	if n != 7 || !ok || errValidacion.ObtenerErroresDeCampos()[0].Campo != "estado" ||
+		errValidacion.ObtenerErroresDeCampos()[0].Codigo != "tipoInvalido" {
+		t.Errorf("Entero devolvió %v %v", n, err)
+	}
+}
+
+func TestHTTPDecodificarConsulta(t *testing.T) {
+	var filtro struct {
+		Pagina   int           `consulta:"pagina,defecto=1,min=1"`
+		Tamanio  uint8         `consulta:"tamanio,defecto=20,max=100"`
+		Orden    string        `consulta:"orden,opciones=nombre|fecha,defecto=nombre"`
+		Texto    string        `consulta:"q,min=3"`
+		Estados  []string      `consulta:"estado,opciones=activo|baja"`
+		Ids      []int         `consulta:"id,max=3"`
+		Desde    time.Time     `consulta:"desde"`
+		Mes      time.Time     `consulta:"mes,formato=2006-01"`
+		Plazo    time.Duration `consulta:"plazo"`
+		Activo   *bool         `consulta:"activo"`
+		Precio   *float64      `consulta:"precio"`
+		Nombre   string        // sin etiqueta, se ignora
+		Ignorado string        `consulta:"-"`
+		privado  string        `consulta:"privado"`
+	}
+	r := httptest.NewRequest("GET", "/personas?q=ana&estado=activo&estado=baja&id=1&id=2&desde=2020-01-02T10:00:00Z"+
+		"&mes=2021-03&plazo=1m30s&precio=9.5&Nombre=Ana&Ignorado=x&privado=x", nil)
+	if err := HTTPDecodificarConsulta(r, &filtro); err != nil {
+		t.Fatal(err)
+	}
+
+	if filtro.Pagina != 1 || filtro.Tamanio != 20 || filtro.Orden != "nombre" || filtro.Texto != "ana" ||
+		!reflect.DeepEqual(filtro.Estados, []string{"activo", "baja"}) || !reflect.DeepEqual(filtro.Ids, []int{1, 2}) ||
+		!filtro.Desde.Equal(time.Date(2020, 1, 2, 10, 0, 0, 0, time.UTC)) ||
+		!filtro.Mes.Equal(time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)) || filtro.Plazo != 90*time.Second ||
+		filtro.Nombre != "" || filtro.Ignorado != "" || filtro.privado != "" {
+		t.Errorf("se obtuvo %+v", filtro)
+	}
+	// los punteros son nil cuando el parámetro no existe
+	if filtro.Activo != nil || filtro.Precio == nil || *filtro.Precio != 9.5 {
+		t.Errorf("se obtuvo activo %v, precio %v", filtro.Activo, filtro.Precio)
+	}
+}
+
+func TestHTTPDecodificarConsultaErroresDeCampos(t *testing.T) {
+	type filtro struct {
+		Pagina  int       `consulta:"pagina,requerido,min=1,max=10"`
+		Orden   string    `consulta:"orden,opciones=nombre|fecha"`
+		Texto   string    `consulta:"q,min=3,max=5"`
+		Estados []string  `consulta:"estado,min=2,opciones=activo|baja"`
+		Desde   time.Time `consulta:"desde"`
+		Mes     time.Time `consulta:"mes,formato=2006-01"`
+		Activo  *bool     `consulta:"activo"`
+	}
+
+	pruebas := []struct {
+		consulta string
+		campo    string
+		codigo   string
+	}{
+		{"", "pagina", "requerido"},
+		{"pagina=", "pagina", "requerido"},
+		{"pagina=uno", "pagina", "tipoInvalido"},
+		{"pagina=0", "pagina", "minimo"},
+		{"pagina=11", "pagina", "maximo"},
+		{"pagina=1&orden=edad", "orden", "opcionInvalida"},
+		{"pagina=1&orden=Nombre", "orden", "opcionInvalida"},
+		{"pagina=1&q=an", "q", "longitudMinima"},
+		{"pagina=1&q=ángeles", "q", "longitudMaxima"},
+		{"pagina=1&estado=activo", "estado", "longitudMinima"},
+		{"pagina=1&estado=activo&estado=otro", "estado", "opcionInvalida"},
+		{"pagina=1&desde=02/01/2020", "desde", "tipoInvalido"},
+		{"pagina=1&mes=2021-03-01", "mes", "tipoInvalido"},
+		{"pagina=1&activo=quizas", "activo", "tipoInvalido"},
+	}
+	for _, p := range pruebas {
+		var destino filtro
+		err := HTTPDecodificarConsulta(httptest.NewRequest("GET", "/personas?"+p.consulta, nil), &destino)
+		errValidacion, ok := ErrorEsValidacion(err)
+		if !ok || len(errValidacion.ObtenerErroresDeCampos()) != 1 {
+			t.Errorf("%q: se obtuvo %v", p.consulta, err)
+			continue
+		}
+		if errCampo := errValidacion.ObtenerErroresDeCampos()[0]; errCampo.Campo != p.campo || errCampo.Codigo != p.codigo {
+			t.Errorf("%q: se obtuvo %v %v, se esperaba %v %v", p.consulta, errCampo.Campo, errCampo.Codigo, p.campo, p.codigo)
+		}
+	}
+}
+
+func TestHTTPDecodificarConsultaUnErrorPorParametro(t *testing.T) {
+	var filtro struct {
+		Pagina int    `consulta:"pagina"`
+		Orden  string `consulta:"orden,opciones=nombre|fecha"`
+	}
+	err := HTTPDecodificarConsulta(httptest.NewRequest("GET", "/personas?pagina=x&orden=edad", nil), &filtro)
+	if errValidacion, ok := ErrorEsValidacion(err); !ok || len(errValidacion.ObtenerErroresDeCampos()) != 2 ||
+		errValidacion.ObtenerHTTPEstado() != HTTPEstadoErrorMalRequerimiento {
+		t.Errorf("se obtuvo %v", err)
+	}
+}
+
+func TestHTTPDecodificarConsultaErroresDelPrograma(t *testing.T) {
+	var sinEtiquetaValida struct {
+		Pagina int `consulta:"pagina,min=uno"`
+	}
+	var tipoNoAdmitido struct {
+		Filtros map[string]string `consulta:"filtros"`
+	}
+	var listaDePunteros struct {
+		Ids []*int `consulta:"id"`
+	}
+
+	pruebas := []struct {
+		nombre  string
+		destino interface{}
+	}{
+		{"destino sin puntero", sinEtiquetaValida},
+		{"etiqueta inválida", &sinEtiquetaValida},
+		{"tipo no admitido", &tipoNoAdmitido},
+		{"lista de punteros", &listaDePunteros},
+	}
+	for _, p := range pruebas {
+		t.Run(p.nombre, func(t *testing.T) {
+			// el error es interno aunque el parámetro no exista en la solicitud
+			for _, consulta := range []string{"", "?pagina=1&filtros=a&id=1"} {
+				err := HTTPDecodificarConsulta(httptest.NewRequest("GET", "/personas"+consulta, nil), p.destino)
+				if errAPIREST, ok := ErrorEsInternoDeServidor(err); !ok || errAPIREST.ObtenerCodigo() != "apirest.errorInternoDeServidor" {
+					t.Errorf("%q: se obtuvo %v", consulta, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package apirest
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CORSOrigenesExpresiones agrega expresiones regulares a los orígenes
+// permitidos. El origen recibido debe coincidir completamente con la
+// expresión: "https://([a-z]+\.)?example\.com".
+// el el campo de cabecera: "Access-Control-Allow-Origin"
+func (o *enrutador) CORSOrigenesExpresiones(expresiones ...string) *enrutador {
+	for _, expresion := range expresiones {
+		er, err := regexp.Compile("^(?:" + expresion + ")$")
+		if err != nil {
+			o.registrarError(ConflictoCORSInvalido, "", "", err, "la expresión de origen CORS %v no es válida: %v", expresion, err)
+			continue
+		}
+		o.cors.expresiones = append(o.cors.expresiones, er)
+	}
+
+	return o
+}
+
+// corsOrigenPermitido devuelve el valor del campo de cabecera
+// "Access-Control-Allow-Origin" para el origen recibido. Si el origen no se
+// encuentra permitido, devuelve falso.
+// Los orígenes permitidos pueden ser:
+// 	"*"                         cualquier origen (sólo sin credenciales)
+// 	"https://app.example.com"   un origen exacto
+// 	"https://*.example.com"     cualquier subdominio de un dominio
+// 	expresiones regulares       ver CORSOrigenesExpresiones
+func (o *enrutador) corsOrigenPermitido(origen string) (string, bool) {
+	for _, permitido := range o.cors.origenes {
+		if permitido == "*" && !o.cors.credenciales {
+			return "*", true
+		}
+	}
+	if origen == "" {
+		return "", false
+	}
+
+	for _, permitido := range o.cors.origenes {
+		switch {
+		case permitido == "*":
+			continue
+		case strings.Contains(permitido, "*"):
+			if coincideOrigenComodin(permitido, origen) {
+				return origen, true
+			}
+		case strings.EqualFold(permitido, origen):
+			return origen, true
+		}
+	}
+	for _, er := range o.cors.expresiones {
+		if er.MatchString(origen) {
+			return origen, true
+		}
+	}
+
+	return "", false
+}
+
+// coincideOrigenComodin verifica que el origen coincida con un patrón de
+// subdominios: "https://*.example.com" permite "https://app.example.com" y
+// "https://a.b.example.com", pero no "https://example.com".
+func coincideOrigenComodin(patron, origen string) bool {
+	var pos = strings.Index(patron, "*")
+	var prefijo, sufijo = strings.ToLower(patron[:pos]), strings.ToLower(patron[pos+1:])
+	origen = strings.ToLower(origen)
+
+	if len(origen) <= len(prefijo)+len(sufijo) || !strings.HasPrefix(origen, prefijo) || !strings.HasSuffix(origen, sufijo) {
+		return false
+	}
+
+	// la parte que reemplaza al comodín sólo puede contener subdominios
+	for _, c := range origen[len(prefijo) : len(origen)-len(sufijo)] {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '.') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// camposSimplesCORS son los campos de cabecera que el explorador puede enviar
+// sin que el endpoint los solicite (CORS-safelisted request headers).
+var camposSimplesCORS = []string{"accept", "accept-language", "content-language", "content-type"}
+
+// esPreflightCORS determina si la solicitud es una verificación previa CORS
+// (preflight): método OPTIONS con los campos de cabecera "Origin" y
+// "Access-Control-Request-Method".
+func esPreflightCORS(r *http.Request) bool {
+	return r.Method == "OPTIONS" && r.Header.Get("Origin") != "" && r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// verificarPreflightCORS verifica que el origen, el método solicitado
+// ("Access-Control-Request-Method") y los campos solicitados
+// ("Access-Control-Request-Headers") se encuentren permitidos.
+func (o *enrutador) verificarPreflightCORS(r *http.Request, detallePtr *patronDeRutaDetalle) error {
+	origen := r.Header.Get("Origin")
+	if _, ok := o.corsOrigenPermitido(origen); !ok {
+		return ErrorNuevoSinPrivilegios("El origen %v no se encuentra permitido", origen).AsignarCodigo("apirest.corsOrigenNoPermitido")
+	}
+
+	metodo := strings.TrimSpace(r.Header.Get("Access-Control-Request-Method"))
+	if _, ok := detallePtr.buscarEndpoint(metodo); !ok {
+		return ErrorNuevoSinPrivilegios("La ruta solicitada no permite el método %v", metodo).AsignarCodigo("apirest.corsMetodoNoPermitido")
+	}
+
+	var noPermitidos []string
+	for _, campo := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+		campo = strings.TrimSpace(campo)
+		if campo == "" || contieneTexto(camposSimplesCORS, campo) || contieneTexto(detallePtr.cors.camposRequeridos, campo) {
+			continue
+		}
+		noPermitidos = append(noPermitidos, campo)
+	}
+	if len(noPermitidos) > 0 {
+		return ErrorNuevoSinPrivilegios("La ruta solicitada no permite los campos de cabecera: %v", strings.Join(noPermitidos, ", ")).AsignarCodigo("apirest.corsCampoNoPermitido").AsignarValoresAdicionales(noPermitidos...)
+	}
+
+	return nil
+}
+
+// cabecerasCORSPreflight devuelve los campos de la cabecera CORS de la
+// respuesta a una verificación previa (preflight).
+func (o *enrutador) cabecerasCORSPreflight(r *http.Request, detallePtr *patronDeRutaDetalle) http.Header {
+	var cabecera = o.cabecerasCORSOrigen(r)
+	cabecera.Add("Vary", "Access-Control-Request-Method")
+	cabecera.Add("Vary", "Access-Control-Request-Headers")
+	cabecera.Set(cors.AccessControlMaxAge, strconv.Itoa(o.cors.duracion))
+	cabecera.Set(cors.AccessControlAllowMethods, strings.Join(detallePtr.cors.metodosPermitidos, ", "))
+	if len(detallePtr.cors.camposRequeridos) > 0 {
+		cabecera.Set(cors.AccessControlAllowHeaders, strings.Join(detallePtr.cors.camposRequeridos, ", "))
+	}
+
+	return cabecera
+}
+
+// cabecerasCORSRespuesta devuelve los campos de la cabecera CORS de la
+// respuesta a una solicitud real (no preflight).
+func (o *enrutador) cabecerasCORSRespuesta(r *http.Request, detallePtr *patronDeRutaDetalle) http.Header {
+	var cabecera = o.cabecerasCORSOrigen(r)
+	if len(detallePtr.cors.camposExpuestos) > 0 && cabecera.Get(cors.AccessControlAllowOrigin) != "" {
+		cabecera.Set(cors.AccessControlExposeHeaders, strings.Join(detallePtr.cors.camposExpuestos, ", "))
+	}
+
+	return cabecera
+}
+
+// cabecerasCORSOrigen devuelve los campos de la cabecera CORS que dependen
+// del origen de la solicitud recibida.
+func (o *enrutador) cabecerasCORSOrigen(r *http.Request) http.Header {
+	var cabecera = make(http.Header)
+
+	origenPermitido, ok := o.corsOrigenPermitido(r.Header.Get("Origin"))
+	if origenPermitido != "*" {
+		// la respuesta depende del origen recibido
+		cabecera.Add("Vary", "Origin")
+	}
+	if ok {
+		cabecera.Set(cors.AccessControlAllowOrigin, origenPermitido)
+		if origenPermitido != "*" && o.cors.credenciales {
+			cabecera.Set(cors.AccessControlAllowCredentials, "true")
+		}
+	}
+
+	return cabecera
+}
+
+// contieneTexto verifica que la lista contenga el texto recibido, sin
+// distinguir mayúsculas de minúsculas.
+func contieneTexto(lista []string, texto string) bool {
+	for _, elemento := range lista {
+		if strings.EqualFold(strings.TrimSpace(elemento), texto) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validarCORS verifica que la configuración CORS sea válida: no se permiten
+// credenciales cuando los orígenes permitidos incluyen "*".
+func (o *enrutador) validarCORS() *errorDeRegistro {
+	if !o.cors.credenciales {
+		return nil
+	}
+	for _, origen := range o.cors.origenes {
+		if origen == "*" {
+			return &errorDeRegistro{
+				conflicto: ConflictoCORSInvalido,
+				mensaje:   "no es posible permitir credenciales CORS con el origen \"*\"",
+			}
+		}
+	}
+
+	return nil
+}
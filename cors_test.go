@@ -0,0 +1,240 @@
+package apirest
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCORSOrigenPermitido(t *testing.T) {
+	r := CrearEnrutador().CORSActivar().
+		CORSOrigenes("https://app.ejemplo.com", "https://*.ejemplo.org").
+		CORSOrigenesExpresiones(`https://([a-z]+\.)?prueba\.com`)
+
+	pruebas := []struct {
+		origen    string
+		permitido bool
+	}{
+		{"https://app.ejemplo.com", true},
+		{"https://APP.ejemplo.com", true},
+		{"http://app.ejemplo.com", false},
+		{"https://otro.ejemplo.com", false},
+		{"https://a.ejemplo.org", true},
+		{"https://a.b.ejemplo.org", true},
+		{"https://ejemplo.org", false},
+		{"https://.ejemplo.org", false},
+		{"https://a_b.ejemplo.org", false},
+		{"https://a.ejemplo.org.malicioso.com", false},
+		{"https://prueba.com", true},
+		{"https://www.prueba.com", true},
+		{"https://www.prueba.com.malicioso.com", false},
+		{"", false},
+	}
+	for _, p := range pruebas {
+		origen, ok := r.corsOrigenPermitido(p.origen)
+		if ok != p.permitido || ok && origen != p.origen {
+			t.Errorf("%q: se obtuvo %q %v, se esperaba %v", p.origen, origen, ok, p.permitido)
+		}
+	}
+}
+
+func TestCORSOrigenesEnLaRespuesta(t *testing.T) {
+	manejador := func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, nil
+	}
+
+	pruebas := []struct {
+		nombre       string
+		origenes     []string
+		credenciales bool
+		origen       string
+		allowOrigin  string
+		credencial   string
+		varyOrigin   bool
+	}{
+		{"cualquier origen", []string{"*"}, false, "https://app.ejemplo.com", "*", "", false},
+		{"cualquier origen sin Origin", []string{"*"}, false, "", "*", "", false},
+		{"origen permitido", []string{"https://a.com", "https://b.com"}, false, "https://b.com", "https://b.com", "", true},
+		{"origen no permitido", []string{"https://a.com", "https://b.com"}, false, "https://c.com", "", "", true},
+		{"origen con credenciales", []string{"https://a.com"}, true, "https://a.com", "https://a.com", "true", true},
+	}
+	for _, p := range pruebas {
+		t.Run(p.nombre, func(t *testing.T) {
+			r := CrearEnrutador().CORSActivar().CORSOrigenes(p.origenes...).COSCredenciales(p.credenciales)
+			r.GET("/personas", manejador)
+
+			var cabecera map[string]string
+			if p.origen != "" {
+				cabecera = map[string]string{"Origin": p.origen}
+			}
+			w := solicitar(r, "GET", "/personas", cabecera)
+			if w.Header().Get("Access-Control-Allow-Origin") != p.allowOrigin ||
+				w.Header().Get("Access-Control-Allow-Credentials") != p.credencial ||
+				contieneTexto(w.Header()["Vary"], "Origin") != p.varyOrigin {
+				t.Errorf("se obtuvo la cabecera %v", w.Header())
+			}
+		})
+	}
+}
+
+func TestCORSCredencialesConCualquierOrigen(t *testing.T) {
+	r := CrearEnrutador().CORSActivar().COSCredenciales(true)
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, nil
+	})
+
+	if err := r.Validar(); !ErrorEsRegistro(err, ConflictoCORSInvalido) {
+		t.Errorf("se esperaba el conflicto CORS, se obtuvo %v", err)
+	}
+
+	// el origen "*" no se responde junto con las credenciales
+	w := solicitar(r, "GET", "/personas", map[string]string{"Origin": "https://a.com"})
+	if w.Header().Get("Access-Control-Allow-Origin") != "" || w.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("se obtuvo la cabecera %v", w.Header())
+	}
+}
+
+func TestCORSOrigenesExpresionInvalida(t *testing.T) {
+	r := CrearEnrutador().CORSActivar().CORSOrigenesExpresiones(`https://(a`)
+
+	if err := r.Validar(); !ErrorEsRegistro(err, ConflictoCORSInvalido) {
+		t.Errorf("se esperaba el conflicto CORS, se obtuvo %v", err)
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	var procesado bool
+	r := CrearEnrutador().CORSActivar().CORSOrigenes("https://a.com").CORSDuracion(600)
+	manejador := func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		procesado = true
+		return nil, nil
+	}
+	r.GET("/personas", manejador).CORSCamposRequeridos("Authorization")
+	r.POST("/personas", manejador)
+
+	pruebas := []struct {
+		nombre string
+		origen string
+		metodo string
+		campos string
+		estado int
+		codigo string
+	}{
+		{"permitida", "https://a.com", "GET", "authorization, Content-Type", http.StatusNoContent, ""},
+		{"sin campos", "https://a.com", "POST", "", http.StatusNoContent, ""},
+		{"HEAD a partir de GET", "https://a.com", "HEAD", "", http.StatusNoContent, ""},
+		{"origen no permitido", "https://b.com", "GET", "", http.StatusForbidden, "apirest.corsOrigenNoPermitido"},
+		{"método no permitido", "https://a.com", "DELETE", "", http.StatusForbidden, "apirest.corsMetodoNoPermitido"},
+		{"campo no permitido", "https://a.com", "GET", "Authorization, X-Secreto", http.StatusForbidden, "apirest.corsCampoNoPermitido"},
+	}
+	for _, p := range pruebas {
+		t.Run(p.nombre, func(t *testing.T) {
+			procesado = false
+			w := solicitar(r, "OPTIONS", "/personas", map[string]string{
+				"Origin":                         p.origen,
+				"Access-Control-Request-Method":  p.metodo,
+				"Access-Control-Request-Headers": p.campos,
+			})
+			if w.Code != p.estado || procesado {
+				t.Fatalf("se obtuvo %d (procesado: %v), se esperaba %d", w.Code, procesado, p.estado)
+			}
+			if p.codigo != "" {
+				if c := decodificarCuerpoDeError(t, w); c.Error.Codigo != p.codigo {
+					t.Errorf("se obtuvo el código %q, se esperaba %q", c.Error.Codigo, p.codigo)
+				}
+				return
+			}
+
+			cabecera := w.Header()
+			if cabecera.Get("Access-Control-Allow-Origin") != "https://a.com" ||
+				cabecera.Get("Access-Control-Allow-Methods") != "GET, POST" ||
+				cabecera.Get("Access-Control-Allow-Headers") != "Authorization" ||
+				cabecera.Get("Access-Control-Max-Age") != "600" ||
+				!contieneTexto(cabecera["Vary"], "Access-Control-Request-Method") {
+				t.Errorf("se obtuvo la cabecera %v", cabecera)
+			}
+		})
+	}
+}
+
+func TestCORSCampoNoPermitidoInformaLosCampos(t *testing.T) {
+	r := CrearEnrutador().CORSActivar()
+	r.GET("/personas", nil)
+
+	w := solicitar(r, "OPTIONS", "/personas", map[string]string{
+		"Origin":                         "https://a.com",
+		"Access-Control-Request-Method":  "GET",
+		"Access-Control-Request-Headers": "X-Uno, Accept, X-Dos",
+	})
+	c := decodificarCuerpoDeError(t, w)
+	if len(c.Error.ValoresAdicionales) != 2 || c.Error.ValoresAdicionales[0] != "X-Uno" || c.Error.ValoresAdicionales[1] != "X-Dos" {
+		t.Errorf("se obtuvo %v", c.Error.ValoresAdicionales)
+	}
+}
+
+func TestOPTIONSReal(t *testing.T) {
+	manejador := func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, nil
+	}
+
+	pruebas := []struct {
+		nombre   string
+		activo   bool
+		cabecera map[string]string
+	}{
+		{"CORS inactivo", false, nil},
+		{"CORS inactivo con Origin", false, map[string]string{"Origin": "https://a.com", "Access-Control-Request-Method": "GET"}},
+		{"CORS activo sin Origin", true, map[string]string{"Access-Control-Request-Method": "GET"}},
+		{"CORS activo sin método solicitado", true, map[string]string{"Origin": "https://a.com"}},
+	}
+	for _, p := range pruebas {
+		t.Run(p.nombre, func(t *testing.T) {
+			r := CrearEnrutador()
+			if p.activo {
+				r.CORSActivar()
+			}
+			r.GET("/personas", manejador)
+			r.PUT("/personas", manejador)
+
+			w := solicitar(r, "OPTIONS", "/personas", p.cabecera)
+			if w.Code != http.StatusNoContent || w.Header().Get("Allow") != "GET, PUT, HEAD, OPTIONS" ||
+				w.Header().Get("Access-Control-Allow-Methods") != "" {
+				t.Errorf("se obtuvo %d %v", w.Code, w.Header())
+			}
+		})
+	}
+}
+
+func TestOPTIONSConEndpointPropio(t *testing.T) {
+	r := CrearEnrutador()
+	r.Manejar("OPTIONS", "/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return "opciones", nil
+	})
+
+	if w := solicitar(r, "OPTIONS", "/personas", nil); w.Code != http.StatusOK || w.Body.String() != `"opciones"` {
+		t.Errorf("se obtuvo %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestCORSEnSolicitudesReales(t *testing.T) {
+	var corsDelContexto map[string]string
+	r := CrearEnrutador().CORSActivar().CORSOrigenes("https://a.com")
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		corsDelContexto = ObtenerCORS(r)
+		return "ok", nil
+	}).CORSCamposExpuestos("X-Total")
+
+	// las cabeceras CORS se escriben sin que el manejador las copie
+	w := solicitar(r, "GET", "/personas", map[string]string{"Origin": "https://a.com"})
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://a.com" || w.Header().Get("Access-Control-Expose-Headers") != "X-Total" {
+		t.Errorf("se obtuvo la cabecera %v", w.Header())
+	}
+	if corsDelContexto["Access-Control-Allow-Origin"] != "https://a.com" {
+		t.Errorf("ObtenerCORS devolvió %v", corsDelContexto)
+	}
+
+	// los campos expuestos no se informan a un origen no permitido
+	w = solicitar(r, "GET", "/personas", map[string]string{"Origin": "https://b.com"})
+	if w.Header().Get("Access-Control-Allow-Origin") != "" || w.Header().Get("Access-Control-Expose-Headers") != "" {
+		t.Errorf("se obtuvo la cabecera %v", w.Header())
+	}
+}
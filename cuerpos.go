@@ -0,0 +1,186 @@
+package apirest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// maximoBytesDeCuerpo es el tamaño máximo por defecto del cuerpo de los
+// mensajes decodificados por HTTPDecodificarJSON (1 MiB).
+const maximoBytesDeCuerpo = 1 << 20
+
+// OpcionesDecodificacion establece el comportamiento de HTTPDecodificarJSON.
+// Los valores cero utilizan el comportamiento por defecto.
+type OpcionesDecodificacion struct {
+	// MaximoBytes es el tamaño máximo del cuerpo del mensaje. Por defecto es de
+	// 1 MiB; un valor negativo no limita el tamaño.
+	MaximoBytes int64
+
+	// PermitirCamposDesconocidos determina que se ignoran los campos del
+	// cuerpo que no existen en el destino (por defecto se rechazan).
+	PermitirCamposDesconocidos bool
+
+	// TiposDeContenido son los tipos de contenido admitidos en el campo de
+	// cabecera "Content-Type". Por defecto se admite "application/json" y los
+	// tipos con sufijo "+json" ("application/merge-patch+json", ...).
+	TiposDeContenido []string
+}
+
+// HTTPDecodificarJSON decodifica el cuerpo JSON del mensaje recibido en el
+// destino (un puntero). Los errores devueltos son de tipo errorAPIREST:
+// 	415 (Mal formato): el tipo de contenido no es admitido.
+// 	413 (Requerimiento muy grande): el cuerpo supera el tamaño máximo.
+// 	400 (Mal requerimiento): el cuerpo es vacío, no es un JSON válido (el
+// 	mensaje técnico informa la línea y la columna), posee campos desconocidos
+// 	o campos con un tipo de dato inválido (informados como errores de campos).
+// Ejemplo:
+// 	var p persona
+// 	if err := apirest.HTTPDecodificarJSON(r, &p, apirest.OpcionesDecodificacion{}); err != nil {
+// 		return nil, err
+// 	}
+func HTTPDecodificarJSON(r *http.Request, destino interface{}, opciones OpcionesDecodificacion) error {
+	if err := verificarTipoDeContenidoJSON(r, opciones.TiposDeContenido); err != nil {
+		return err
+	}
+
+	maximo := opciones.MaximoBytes
+	if maximo == 0 {
+		maximo = maximoBytesDeCuerpo
+	}
+	if maximo > 0 && r.ContentLength > maximo {
+		return errorCuerpoMuyGrande(maximo)
+	}
+	if r.Body == nil || r.Body == http.NoBody {
+		return ErrorNuevoMalRequerimiento("El cuerpo del mensaje es vacío").
+			AsignarCodigo("apirest.cuerpoVacio")
+	}
+
+	var lector io.Reader = r.Body
+	if maximo > 0 {
+		lector = io.LimitReader(r.Body, maximo+1)
+	}
+	cuerpo, err := ioutil.ReadAll(lector)
+	if err != nil {
+		return ErrorEnvolverMalRequerimiento(err, "No es posible leer el cuerpo del mensaje").
+			AsignarCodigo("apirest.cuerpoIlegible").
+			AsignarMensajeTecnico("%v", err)
+	}
+	if maximo > 0 && int64(len(cuerpo)) > maximo {
+		return errorCuerpoMuyGrande(maximo)
+	}
+
+	decodificador := json.NewDecoder(bytes.NewReader(cuerpo))
+	if !opciones.PermitirCamposDesconocidos {
+		decodificador.DisallowUnknownFields()
+	}
+	if err := decodificador.Decode(destino); err != nil {
+		return errorDecodificacionJSON(err, cuerpo)
+	}
+	// el cuerpo no debe poseer otro valor a continuación del valor decodificado
+	siguiente := bytes.TrimLeft(cuerpo[decodificador.InputOffset():], " \t\r\n")
+	if len(siguiente) > 0 {
+		linea, columna := posicionEnTexto(cuerpo, int64(len(cuerpo)-len(siguiente)))
+		return ErrorNuevoMalRequerimiento("El cuerpo del mensaje no es un JSON válido").
+			AsignarCodigo("apirest.cuerpoMalFormado").
+			AsignarMensajeTecnico("línea %d, columna %d: el cuerpo posee más de un valor JSON", linea, columna)
+	}
+
+	return nil
+}
+
+// verificarTipoDeContenidoJSON verifica que el campo de cabecera
+// "Content-Type" sea uno de los tipos de contenido admitidos.
+func verificarTipoDeContenidoJSON(r *http.Request, admitidos []string) error {
+	tipo, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err == nil {
+		if len(admitidos) == 0 && (tipo == "application/json" || strings.HasSuffix(tipo, "+json")) {
+			return nil
+		}
+		for _, admitido := range admitidos {
+			if strings.EqualFold(tipo, admitido) {
+				return nil
+			}
+		}
+	}
+
+	return ErrorNuevoMalFormato("El tipo de contenido %q no es admitido", r.Header.Get("Content-Type")).
+		AsignarCodigo("apirest.cuerpoTipoDeContenidoNoAdmitido")
+}
+
+func errorCuerpoMuyGrande(maximo int64) *errorAPIREST {
+	return ErrorNuevoRequerimientoMuyGrande("El cuerpo del mensaje supera el tamaño máximo de %d bytes", maximo).
+		AsignarCodigo("apirest.cuerpoMuyGrande")
+}
+
+// errorDecodificacionJSON convierte el error de decodificación recibido en un
+// error de tipo 400 (Mal requerimiento).
+func errorDecodificacionJSON(err error, cuerpo []byte) *errorAPIREST {
+	var errSintaxis *json.SyntaxError
+	var errTipo *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		return ErrorEnvolverMalRequerimiento(err, "El cuerpo del mensaje es vacío").
+			AsignarCodigo("apirest.cuerpoVacio")
+
+	case errors.As(err, &errSintaxis):
+		// el desplazamiento se encuentra a continuación del caracter inválido
+		linea, columna := posicionEnTexto(cuerpo, errSintaxis.Offset-1)
+		return ErrorEnvolverMalRequerimiento(err, "El cuerpo del mensaje no es un JSON válido").
+			AsignarCodigo("apirest.cuerpoMalFormado").
+			AsignarMensajeTecnico("línea %d, columna %d: %v", linea, columna, err)
+
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		linea, columna := posicionEnTexto(cuerpo, int64(len(cuerpo)))
+		return ErrorEnvolverMalRequerimiento(err, "El cuerpo del mensaje no es un JSON válido").
+			AsignarCodigo("apirest.cuerpoMalFormado").
+			AsignarMensajeTecnico("línea %d, columna %d: %v", linea, columna, err)
+
+	case errors.As(err, &errTipo):
+		linea, columna := posicionEnTexto(cuerpo, errTipo.Offset)
+		var partes []interface{}
+		if errTipo.Field != "" {
+			for _, parte := range strings.Split(errTipo.Field, ".") {
+				partes = append(partes, parte)
+			}
+		}
+		return ErrorEnvolverMalRequerimiento(err, "El cuerpo del mensaje posee campos con un tipo de dato inválido").
+			AsignarCodigo(codigoDeValidacion).
+			AsignarMensajeTecnico("línea %d, columna %d: %v", linea, columna, err).
+			AgregarErrorDeCampo(PunteroJSON(partes...), "tipoInvalido",
+				"El tipo de dato del campo es inválido", map[string]interface{}{"tipo": errTipo.Type.String()})
+
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// el paquete encoding/json no posee un tipo para este error
+		campo := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return ErrorEnvolverMalRequerimiento(err, "El cuerpo del mensaje posee el campo desconocido %q", campo).
+			AsignarCodigo("apirest.cuerpoCampoDesconocido").
+			AsignarMensajeTecnico("%v", err).
+			AsignarValoresAdicionales(campo)
+	}
+
+	return ErrorEnvolverMalRequerimiento(err, "No es posible decodificar el cuerpo del mensaje").
+		AsignarCodigo("apirest.cuerpoMalFormado").
+		AsignarMensajeTecnico("%v", err)
+}
+
+// posicionEnTexto devuelve la línea y la columna (comenzando en 1) que
+// corresponden al desplazamiento recibido dentro del texto.
+func posicionEnTexto(texto []byte, desplazamiento int64) (linea, columna int) {
+	if desplazamiento < 0 {
+		desplazamiento = 0
+	}
+	if desplazamiento > int64(len(texto)) {
+		desplazamiento = int64(len(texto))
+	}
+	anterior := texto[:desplazamiento]
+	linea = bytes.Count(anterior, []byte("\n")) + 1
+	columna = len(anterior) - bytes.LastIndexByte(anterior, '\n')
+
+	return linea, columna
+}
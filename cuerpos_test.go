@@ -0,0 +1,141 @@
+package apirest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// personaDePrueba es el destino de las pruebas de decodificación.
+type personaDePrueba struct {
+	Nombre    string `json:"nombre"`
+	Direccion struct {
+		Numero int `json:"numero"`
+	} `json:"direccion"`
+}
+
+// solicitudConCuerpo crea una solicitud POST con el tipo de contenido y el
+// cuerpo recibidos.
+func solicitudConCuerpo(tipo, cuerpo string) *http.Request {
+	r := httptest.NewRequest("POST", "/personas", strings.NewReader(cuerpo))
+	if tipo != "" {
+		r.Header.Set("Content-Type", tipo)
+	}
+
+	return r
+}
+
+func TestHTTPDecodificarJSON(t *testing.T) {
+	var p personaDePrueba
+	r := solicitudConCuerpo("application/json; charset=utf-8", `{"nombre": "Ana", "direccion": {"numero": 12}}`+"\n")
+	if err := HTTPDecodificarJSON(r, &p, OpcionesDecodificacion{}); err != nil {
+		t.Fatal(err)
+	}
+	if p.Nombre != "Ana" || p.Direccion.Numero != 12 {
+		t.Errorf("se obtuvo %+v", p)
+	}
+}
+
+func TestHTTPDecodificarJSONErrores(t *testing.T) {
+	pruebas := []struct {
+		nombre         string
+		tipo           string
+		cuerpo         string
+		opciones       OpcionesDecodificacion
+		estado         HTTPEstado
+		codigo         string
+		mensajeTecnico string
+	}{
+		{"sin tipo de contenido", "", `{}`, OpcionesDecodificacion{}, 415, "apirest.cuerpoTipoDeContenidoNoAdmitido", ""},
+		{"tipo no admitido", "text/plain", `{}`, OpcionesDecodificacion{}, 415, "apirest.cuerpoTipoDeContenidoNoAdmitido", ""},
+		{"tipo no admitido por las opciones", "application/merge-patch+json", `{}`,
+			OpcionesDecodificacion{TiposDeContenido: []string{"application/json"}}, 415, "apirest.cuerpoTipoDeContenidoNoAdmitido", ""},
+		{"muy grande", "application/json", `{"nombre": "Ana"}`, OpcionesDecodificacion{MaximoBytes: 8}, 413, "apirest.cuerpoMuyGrande", ""},
+		{"vacío", "application/json", "", OpcionesDecodificacion{}, 400, "apirest.cuerpoVacio", ""},
+		{"mal formado", "application/json", "{\n  \"nombre\": ,\n}", OpcionesDecodificacion{}, 400, "apirest.cuerpoMalFormado", "línea 2, columna 13:"},
+		{"incompleto", "application/json", "{\n  \"nombre\": \"Ana\"", OpcionesDecodificacion{}, 400, "apirest.cuerpoMalFormado", "línea 2, columna 18:"},
+		{"más de un valor", "application/json", "{}\n{}", OpcionesDecodificacion{}, 400, "apirest.cuerpoMalFormado", "línea 2, columna 1:"},
+		{"campo desconocido", "application/json", `{"apellido": "Pérez"}`, OpcionesDecodificacion{}, 400, "apirest.cuerpoCampoDesconocido", ""},
+		{"tipo de dato inválido", "application/json", `{"direccion": {"numero": "doce"}}`, OpcionesDecodificacion{}, 400, "apirest.validacion", "línea 1, columna"},
+	}
+	for _, p := range pruebas {
+		t.Run(p.nombre, func(t *testing.T) {
+			var destino personaDePrueba
+			err := HTTPDecodificarJSON(solicitudConCuerpo(p.tipo, p.cuerpo), &destino, p.opciones)
+			errAPIREST, ok := ErrorEsAPIREST(err)
+			if !ok || errAPIREST.ObtenerHTTPEstado() != p.estado || errAPIREST.ObtenerCodigo() != p.codigo {
+				t.Fatalf("se obtuvo %v, se esperaba %d %q", err, p.estado, p.codigo)
+			}
+			if !strings.HasPrefix(errAPIREST.ObtenerMensajeTecnico(), p.mensajeTecnico) {
+				t.Errorf("se obtuvo el mensaje técnico %q, se esperaba %q", errAPIREST.ObtenerMensajeTecnico(), p.mensajeTecnico)
+			}
+		})
+	}
+}
+
+func TestHTTPDecodificarJSONSinCuerpo(t *testing.T) {
+	for _, cuerpo := range []io.ReadCloser{nil, http.NoBody} {
+		r := solicitudConCuerpo("application/json", "")
+		r.Body = cuerpo
+
+		var p personaDePrueba
+		if err := HTTPDecodificarJSON(r, &p, OpcionesDecodificacion{}); !esErrorConCodigo(err, "apirest.cuerpoVacio") {
+			t.Errorf("%v: se obtuvo %v", cuerpo, err)
+		}
+	}
+}
+
+func TestHTTPDecodificarJSONCampoDesconocido(t *testing.T) {
+	var p personaDePrueba
+	err := HTTPDecodificarJSON(solicitudConCuerpo("application/json", `{"nombre": "Ana", "apellido": "Pérez"}`), &p, OpcionesDecodificacion{})
+	if errAPIREST, ok := ErrorEsAPIREST(err); !ok || !reflect.DeepEqual(errAPIREST.ObtenerValoresAdicionales(), []string{"apellido"}) {
+		t.Errorf("se obtuvo %v", err)
+	}
+
+	// las opciones permiten ignorar los campos desconocidos
+	opciones := OpcionesDecodificacion{PermitirCamposDesconocidos: true}
+	if err := HTTPDecodificarJSON(solicitudConCuerpo("application/json", `{"nombre": "Ana", "apellido": "Pérez"}`), &p, opciones); err != nil || p.Nombre != "Ana" {
+		t.Errorf("se obtuvo %v %+v", err, p)
+	}
+}
+
+func TestHTTPDecodificarJSONTipoDeDatoInvalido(t *testing.T) {
+	var p personaDePrueba
+	err := HTTPDecodificarJSON(solicitudConCuerpo("application/json", `{"direccion": {"numero": "doce"}}`), &p, OpcionesDecodificacion{})
+
+	errValidacion, ok := ErrorEsValidacion(err)
+	if !ok {
+		t.Fatalf("se obtuvo %v", err)
+	}
+	esperados := []errorDeCampo{{"/direccion/numero", "tipoInvalido", "El tipo de dato del campo es inválido", map[string]interface{}{"tipo": "int"}}}
+	if !reflect.DeepEqual(errValidacion.ObtenerErroresDeCampos(), esperados) {
+		t.Errorf("se obtuvo %+v, se esperaba %+v", errValidacion.ObtenerErroresDeCampos(), esperados)
+	}
+}
+
+func TestHTTPDecodificarJSONTamanio(t *testing.T) {
+	cuerpo := `{"nombre": "` + strings.Repeat("a", maximoBytesDeCuerpo) + `"}`
+	var p personaDePrueba
+
+	// el tamaño máximo se verifica aunque no se informe el campo "Content-Length"
+	r := solicitudConCuerpo("application/json", cuerpo)
+	r.ContentLength = -1
+	if err := HTTPDecodificarJSON(r, &p, OpcionesDecodificacion{}); !esErrorConCodigo(err, "apirest.cuerpoMuyGrande") {
+		t.Errorf("se obtuvo %v", err)
+	}
+
+	// un tamaño máximo negativo no limita el cuerpo
+	if err := HTTPDecodificarJSON(solicitudConCuerpo("application/json", cuerpo), &p, OpcionesDecodificacion{MaximoBytes: -1}); err != nil {
+		t.Errorf("se obtuvo %v", err)
+	}
+}
+
+// esErrorConCodigo informa si el error es de tipo errorAPIREST y posee el
+// código recibido.
+func esErrorConCodigo(err error, codigo string) bool {
+	errAPIREST, ok := ErrorEsAPIREST(err)
+	return ok && errAPIREST.ObtenerCodigo() == codigo
+}
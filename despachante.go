@@ -1,8 +1,12 @@
 package apirest
 
 import (
+	"bufio"
 	"bytes"
+	"fmt"
+	"net"
 	"net/http"
+	"strconv"
 )
 
 // HTTPEstado es el tipo que establece el código de estado de respuesta HTTP.
@@ -13,34 +17,93 @@ func (t HTTPEstado) obtenerEntero() int {
 	return int(t)
 }
 
-// Códigos de estados de respuesta HTTP:
-// 	HTTPEstadoOk                      = 200
-// 	HTTPEstadoOkCreado                = 201
-// 	HTTPEstadoOkSinContenido          = 204
-// 	HTTPEstadoMalRequerimiento        = 400
-// 	HTTPEstadoSinAutorizacion         = 401
-// 	HTTPEstadoSinPrivilegios          = 403
-// 	HTTPEstadoNoEncontrado            = 404
-// 	HTTPEstadoMetodoNoImplementado    = 405
-// 	HTTPEstadoRequerimientoMuyGrande  = 413
-// 	HTTPEstadoURIMuyGrande            = 414
-// 	HTTPEstadoMalFormato              = 415
-// 	HTTPEstadoErrorInternoDeServidor  = 500
+// Códigos de estados de respuesta HTTP registrados en IANA:
+// 	https://www.iana.org/assignments/http-status-codes
+// El texto de cada código de estado se obtiene con String().
 const (
+	// Informativos (1xx)
+	HTTPEstadoInformacionContinuar           HTTPEstado = 100
+	HTTPEstadoInformacionCambiandoProtocolos HTTPEstado = 101
+	HTTPEstadoInformacionProcesando          HTTPEstado = 102
+	HTTPEstadoInformacionIndiciosTempranos   HTTPEstado = 103
+
+	// Exitosos (2xx)
 	HTTPEstadoOk                          HTTPEstado = 200
 	HTTPEstadoOkCreado                    HTTPEstado = 201
+	HTTPEstadoOkAceptado                  HTTPEstado = 202
+	HTTPEstadoOkInformacionNoAutoritativa HTTPEstado = 203
 	HTTPEstadoOkSinContenido              HTTPEstado = 204
-	HTTPEstadoErrorMalRequerimiento       HTTPEstado = 400
-	HTTPEstadoErrorSinAutorizacion        HTTPEstado = 401
-	HTTPEstadoErrorSinPrivilegios         HTTPEstado = 403
-	HTTPEstadoErrorNoEncontrado           HTTPEstado = 404
-	HTTPEstadoErrorMetodoNoImplementado   HTTPEstado = 405
-	HTTPEstadoErrorRequerimientoMuyGrande HTTPEstado = 413
-	HTTPEstadoErrorURIMuyGrande           HTTPEstado = 414
-	HTTPEstadoErrorMalFormato             HTTPEstado = 415
-	HTTPEstadoErrorInternoDeServidor      HTTPEstado = 500
+	HTTPEstadoOkRestablecerContenido      HTTPEstado = 205
+	HTTPEstadoOkContenidoParcial          HTTPEstado = 206
+	HTTPEstadoOkMultiEstado               HTTPEstado = 207
+	HTTPEstadoOkYaInformado               HTTPEstado = 208
+	HTTPEstadoOkIMUsado                   HTTPEstado = 226
+
+	// Redirecciones (3xx)
+	HTTPEstadoRedireccionMultiplesOpciones     HTTPEstado = 300
+	HTTPEstadoRedireccionMovidoPermanentemente HTTPEstado = 301
+	HTTPEstadoRedireccionEncontrado            HTTPEstado = 302
+	HTTPEstadoRedireccionVerOtro               HTTPEstado = 303
+	HTTPEstadoRedireccionNoModificado          HTTPEstado = 304
+	HTTPEstadoRedireccionUsarProxy             HTTPEstado = 305
+	HTTPEstadoRedireccionTemporal              HTTPEstado = 307
+	HTTPEstadoRedireccionPermanente            HTTPEstado = 308
+
+	// Errores del cliente (4xx)
+	HTTPEstadoErrorMalRequerimiento              HTTPEstado = 400
+	HTTPEstadoErrorSinAutorizacion               HTTPEstado = 401
+	HTTPEstadoErrorPagoRequerido                 HTTPEstado = 402
+	HTTPEstadoErrorSinPrivilegios                HTTPEstado = 403
+	HTTPEstadoErrorNoEncontrado                  HTTPEstado = 404
+	HTTPEstadoErrorMetodoNoImplementado          HTTPEstado = 405
+	HTTPEstadoErrorNoAceptable                   HTTPEstado = 406
+	HTTPEstadoErrorAutenticacionDeProxyRequerida HTTPEstado = 407
+	HTTPEstadoErrorTiempoDeRequerimientoAgotado  HTTPEstado = 408
+	HTTPEstadoErrorConflicto                     HTTPEstado = 409
+	HTTPEstadoErrorYaNoDisponible                HTTPEstado = 410
+	HTTPEstadoErrorLongitudRequerida             HTTPEstado = 411
+	HTTPEstadoErrorPrecondicionFallida           HTTPEstado = 412
+	HTTPEstadoErrorRequerimientoMuyGrande        HTTPEstado = 413
+	HTTPEstadoErrorURIMuyGrande                  HTTPEstado = 414
+	HTTPEstadoErrorMalFormato                    HTTPEstado = 415
+	HTTPEstadoErrorRangoNoSatisfactorio          HTTPEstado = 416
+	HTTPEstadoErrorExpectativaFallida            HTTPEstado = 417
+	HTTPEstadoErrorSoyUnaTetera                  HTTPEstado = 418
+	HTTPEstadoErrorRequerimientoMalDirigido      HTTPEstado = 421
+	HTTPEstadoErrorEntidadNoProcesable           HTTPEstado = 422
+	HTTPEstadoErrorRecursoBloqueado              HTTPEstado = 423
+	HTTPEstadoErrorDependenciaFallida            HTTPEstado = 424
+	HTTPEstadoErrorDemasiadoTemprano             HTTPEstado = 425
+	HTTPEstadoErrorActualizacionRequerida        HTTPEstado = 426
+	HTTPEstadoErrorPrecondicionRequerida         HTTPEstado = 428
+	HTTPEstadoErrorDemasiadosRequerimientos      HTTPEstado = 429
+	HTTPEstadoErrorCamposDeCabeceraMuyGrandes    HTTPEstado = 431
+	HTTPEstadoErrorNoDisponiblePorRazonesLegales HTTPEstado = 451
+
+	// Errores del servidor (5xx)
+	HTTPEstadoErrorInternoDeServidor             HTTPEstado = 500
+	HTTPEstadoErrorNoImplementado                HTTPEstado = 501
+	HTTPEstadoErrorPuertaDeEnlaceIncorrecta      HTTPEstado = 502
+	HTTPEstadoErrorServicioNoDisponible          HTTPEstado = 503
+	HTTPEstadoErrorTiempoDePuertaDeEnlaceAgotado HTTPEstado = 504
+	HTTPEstadoErrorVersionHTTPNoSoportada        HTTPEstado = 505
+	HTTPEstadoErrorVarianteTambienNegocia        HTTPEstado = 506
+	HTTPEstadoErrorAlmacenamientoInsuficiente    HTTPEstado = 507
+	HTTPEstadoErrorBucleDetectado                HTTPEstado = 508
+	HTTPEstadoErrorNoExtendido                   HTTPEstado = 510
+	HTTPEstadoErrorAutenticacionDeRedRequerida   HTTPEstado = 511
 )
 
+// String devuelve el texto del código de estado HTTP (por ejemplo, "Not Found"
+// para el código 404). Si el código no se encuentra registrado, devuelve el
+// número del código.
+func (t HTTPEstado) String() string {
+	if texto := http.StatusText(t.obtenerEntero()); texto != "" {
+		return texto
+	}
+	return strconv.Itoa(t.obtenerEntero())
+}
+
 // HTTPContenido establece el tipo de contenido dentro del cuerpo de los
 // mensajes HTTP.
 type HTTPContenido string
@@ -52,24 +115,24 @@ func (t HTTPContenido) obtenerTexto() string {
 
 // Códigos de tipos de contenido dentro del cuerpo de los mensajes HTTP.
 // 	HTTPContenidoSinContenido      = ""
-// 	HTTPContenidoApplicationJSON   = "application/json charset=utf-8"
+// 	HTTPContenidoApplicationJSON   = "application/json; charset=utf-8"
 // 	HTTPContenidoApplicationXML    = "application/xml; charset=utf-8"
 // 	HTTPContenidoApplicationRTF    = "application/rtf; charset=utf-8"
-//	HTTPContenidoApplicationPDF    = "application/pdf"
-// 	HTTPContenidoApplicationGZIP   = "applicatio/gzip"
-// 	HTTPContenidoApplicationHTTP   = "applicatio/http"
-// 	HTTPContenidoApplicationMSWord = "applicatio/msword"
-// 	HTTPContenidoTextHTML          = "tex/html; charset=utf-8"
-//	HTTPContenidoImagePNG          = "image/png"
-// 	HTTPContenidoImageJPEG         = "imag/jpeg"
-// 	HTTPContenidoImageGIF          = "imag/gif"
-// 	HTTPContenidoTextPlain         = "tex/plain; charset=utf-8"
+// 	HTTPContenidoApplicationPDF    = "application/pdf"
+// 	HTTPContenidoApplicationGZIP   = "application/gzip"
+// 	HTTPContenidoApplicationHTTP   = "application/http"
+// 	HTTPContenidoApplicationMSWord = "application/msword"
+// 	HTTPContenidoTextHTML          = "text/html; charset=utf-8"
+// 	HTTPContenidoImagePNG          = "image/png"
+// 	HTTPContenidoImageJPEG         = "image/jpeg"
+// 	HTTPContenidoImageGIF          = "image/gif"
+// 	HTTPContenidoTextPlain         = "text/plain; charset=utf-8"
 // 	HTTPContenidoTextCSV           = "text/csv; charset=utf-8"
 // 	HTTPContenidoTextXML           = "text/xml; charset=utf-8"
 // 	HTTPContenidoTextRTF           = "text/rtf; charset=utf-8"
 const (
 	HTTPContenidoSinContenido      HTTPContenido = ""
-	HTTPContenidoApplicationJSON   HTTPContenido = "application/json charset=utf-8"
+	HTTPContenidoApplicationJSON   HTTPContenido = "application/json; charset=utf-8"
 	HTTPContenidoApplicationXML    HTTPContenido = "application/xml; charset=utf-8"
 	HTTPContenidoApplicationRTF    HTTPContenido = "application/rtf; charset=utf-8"
 	HTTPContenidoApplicationPDF    HTTPContenido = "application/pdf"
@@ -118,3 +181,106 @@ func HTTPObtenerCuerpo(r *http.Request) string {
 
 	return buf.String()
 }
+
+// escritorDeRespuesta envuelve al http.ResponseWriter recibido por el
+// enrutador para detectar si la función (ManejadorFunc) ya escribió la
+// respuesta por sí misma.
+// Para las solicitudes HEAD, el cuerpo de la respuesta se descarta, pero se
+// contabiliza su longitud para informarla en el campo "Content-Length".
+type escritorDeRespuesta struct {
+	http.ResponseWriter
+	escrito  bool // determina si ya se escribió la cabecera o el cuerpo de la respuesta
+	esHEAD   bool // determina que la solicitud es HEAD (se descarta el cuerpo)
+	estado   int  // código de estado HTTP escrito
+	longitud int  // cantidad de bytes del cuerpo escritos (o descartados en HEAD)
+}
+
+// WriteHeader escribe el código de estado HTTP de la respuesta. En las
+// solicitudes HEAD, la escritura se demora hasta conocer la longitud del
+// cuerpo (ver finalizarHEAD).
+func (o *escritorDeRespuesta) WriteHeader(estado int) {
+	if o.estado != 0 {
+		return
+	}
+	o.escrito, o.estado = true, estado
+	if o.esHEAD {
+		return
+	}
+	o.ResponseWriter.WriteHeader(estado)
+}
+
+// Write escribe el cuerpo de la respuesta.
+func (o *escritorDeRespuesta) Write(b []byte) (int, error) {
+	if o.estado == 0 {
+		o.WriteHeader(http.StatusOK)
+	}
+	if o.esHEAD {
+		o.longitud += len(b)
+		return len(b), nil
+	}
+
+	n, err := o.ResponseWriter.Write(b)
+	o.longitud += n
+	return n, err
+}
+
+// finalizarHEAD escribe la cabecera demorada de una solicitud HEAD, junto con
+// la longitud del cuerpo descartado.
+func (o *escritorDeRespuesta) finalizarHEAD() {
+	if !o.esHEAD || o.estado == 0 {
+		return
+	}
+	if o.longitud > 0 && o.Header().Get("Content-Length") == "" {
+		o.Header().Set("Content-Length", strconv.Itoa(o.longitud))
+	}
+	o.ResponseWriter.WriteHeader(o.estado)
+}
+
+// Flush envía al cliente los datos almacenados en el buffer, en caso que el
+// http.ResponseWriter original lo permita.
+func (o *escritorDeRespuesta) Flush() {
+	if o.esHEAD {
+		return
+	}
+	if f, ok := o.ResponseWriter.(http.Flusher); ok {
+		o.escrito = true
+		f.Flush()
+	}
+}
+
+// Hijack permite tomar el control de la conexión, en caso que el
+// http.ResponseWriter original lo permita.
+func (o *escritorDeRespuesta) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := o.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("el http.ResponseWriter no permite tomar el control de la conexión")
+	}
+	o.escrito = true
+	return h.Hijack()
+}
+
+// Unwrap devuelve el http.ResponseWriter original.
+func (o *escritorDeRespuesta) Unwrap() http.ResponseWriter {
+	return o.ResponseWriter
+}
+
+// responderResultado construye la respuesta a partir de los valores devueltos
+// por una función (ManejadorFunc):
+// 	- si existe un error, se responde el error en el sobre estándar.
+// 	- si el valor es nulo, se responde 204 (sin contenido).
+// 	- en otro caso, se serializa el valor según el tipo de contenido aceptado
+// 	  por el cliente (ver HTTPResponderValor). Se responde 201 (creado) para
+// 	  POST y 200 para el resto.
+func responderResultado(w http.ResponseWriter, r *http.Request, valor interface{}, err error) {
+	if err != nil {
+		responderErrorAPIREST(w, err, ObtenerIDDeSolicitud(r))
+		return
+	}
+
+	var estadoHTTP = HTTPEstadoOk
+	if r.Method == "POST" {
+		estadoHTTP = HTTPEstadoOkCreado
+	}
+
+	HTTPResponderValor(w, r, estadoHTTP, valor)
+}
@@ -0,0 +1,52 @@
+package apirest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHTTPEstadoString(t *testing.T) {
+	pruebas := []struct {
+		estado   HTTPEstado
+		esperado string
+	}{
+		{HTTPEstadoOk, "OK"},
+		{HTTPEstadoErrorNoEncontrado, "Not Found"},
+		{HTTPEstadoErrorDemasiadosRequerimientos, "Too Many Requests"},
+		{HTTPEstadoErrorTiempoDePuertaDeEnlaceAgotado, "Gateway Timeout"},
+		{HTTPEstado(299), "299"},
+		{HTTPEstado(0), "0"},
+	}
+	for _, p := range pruebas {
+		if texto := p.estado.String(); texto != p.esperado {
+			t.Errorf("%d: se obtuvo %q, se esperaba %q", int(p.estado), texto, p.esperado)
+		}
+	}
+}
+
+func TestReintentarDespuesEnLaRespuesta(t *testing.T) {
+	pruebas := []struct {
+		nombre     string
+		err        error
+		estado     int
+		retryAfter string
+	}{
+		{"429", ErrorNuevoDemasiadosRequerimientos("Límite alcanzado").AsignarReintentarDespues(30 * time.Second), 429, "30"},
+		{"503 con fracción de segundo", ErrorNuevoServicioNoDisponible("En mantenimiento").AsignarReintentarDespues(100 * time.Millisecond), 503, "1"},
+		{"503 sin tiempo", ErrorNuevoServicioNoDisponible("En mantenimiento"), 503, ""},
+	}
+	for _, p := range pruebas {
+		t.Run(p.nombre, func(t *testing.T) {
+			r := CrearEnrutador()
+			r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+				return nil, p.err
+			})
+
+			w := solicitar(r, "GET", "/personas", nil)
+			if w.Code != p.estado || w.Header().Get("Retry-After") != p.retryAfter {
+				t.Errorf("se obtuvo %d %q, se esperaba %d %q", w.Code, w.Header().Get("Retry-After"), p.estado, p.retryAfter)
+			}
+		})
+	}
+}
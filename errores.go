@@ -2,8 +2,10 @@ package apirest
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"runtime"
 	"strings"
 	"time"
@@ -13,29 +15,57 @@ import (
 )
 
 type errorRastro struct {
-	Paquete       string // nombre del paquete donde se originó el error
-	Archivo       string // nombre del archivo donde se originó el error
-	Funcion       string // nombre de la función donde se originó el error
-	NroLinea      int    // número de línea donde se originó el error
-	Observaciones string // observaciones adicionales del rastro actual (es opcional)
+	Paquete       string `json:"paquete"`                 // nombre del paquete donde se originó el error
+	Archivo       string `json:"archivo"`                 // nombre del archivo donde se originó el error
+	Funcion       string `json:"funcion"`                 // nombre de la función donde se originó el error
+	NroLinea      int    `json:"nroLinea"`                // número de línea donde se originó el error
+	Observaciones string `json:"observaciones,omitempty"` // observaciones adicionales del rastro actual (es opcional)
 }
 
 type errorAPIREST struct {
-	estadoHTTP         HTTPEstado  // código de estado HTTP
-	codigo             string      // código de error
-	mensaje            string      // mensaje de error
-	mensajeTecnico     string      // mensaje técnico para ser leído por el desarrollador
-	valoresAdicionales []string    // lista de textos que puede ser utilizados para exponer campos con error
-	uuid               string      // identificador único universal del error
-	errAnterior        error       // error anterior
-	rastro             errorRastro // rastro/ubicación donde se originó el error
+	estadoHTTP         HTTPEstado     // código de estado HTTP
+	codigo             string         // código de error
+	mensaje            string         // mensaje de error
+	mensajeTecnico     string         // mensaje técnico para ser leído por el desarrollador
+	valoresAdicionales []string       // lista de textos que puede ser utilizados para exponer campos con error
+	uuid               string         // identificador único universal del error
+	erroresDeCampos    []errorDeCampo // errores de validación de los campos (ver AgregarErrorDeCampo)
+	reintentarDespues  time.Duration  // tiempo que el cliente debe esperar antes de reintentar
+	errAnterior        error          // error anterior
+	rastro             errorRastro    // rastro/ubicación donde se originó el error
 }
 
 // Error retorna el mensaje de error (implementa la interface error).
+// Si el error no posee mensaje (por ejemplo, un error creado por
+// ErrorNuevoRastro), retorna el mensaje del error anterior.
 func (o *errorAPIREST) Error() string {
+	if o.mensaje == "" && o.errAnterior != nil {
+		return o.errAnterior.Error()
+	}
 	return o.mensaje
 }
 
+// Unwrap devuelve el error anterior (permite utilizar errors.Is y errors.As).
+func (o *errorAPIREST) Unwrap() error {
+	return o.errAnterior
+}
+
+// Is verifica que el error coincida con el error objetivo (permite utilizar
+// errors.Is). Coinciden cuando el objetivo es de tipo errorAPIREST y su código
+// de estado HTTP y su código (los que posean valor) son iguales a los del
+// error actual.
+// Ejemplo:
+// 	errors.Is(err, apirest.ErrorNuevoNoEncontrado("").AsignarCodigo("personas.inexistente"))
+func (o *errorAPIREST) Is(objetivo error) bool {
+	errObjetivo, ok := objetivo.(*errorAPIREST)
+	if !ok || (errObjetivo.estadoHTTP == 0 && errObjetivo.codigo == "") {
+		return false
+	}
+
+	return (errObjetivo.estadoHTTP == 0 || errObjetivo.estadoHTTP == o.estadoHTTP) &&
+		(errObjetivo.codigo == "" || errObjetivo.codigo == o.codigo)
+}
+
 // AsignarCodigo asiga el código de error.
 func (o *errorAPIREST) AsignarCodigo(codigo string) *errorAPIREST {
 	o.codigo = codigo
@@ -68,7 +98,29 @@ func (o *errorAPIREST) AsignarValoresAdicionales(valores ...string) *errorAPIRES
 
 // AsignarUUID asiga un identificador único universal al error actual
 // para que en caso de ser registrado, pueda realizarse un seguimiento.
-// Para generar el UUID, se utiliza la versión 4 (random).
+// Para generar el UUID, se utiliza la versión 4 (random) (ver generarUUID).
+func (o *errorAPIREST) AsignarUUID() *errorAPIREST {
+	o.uuid = generarUUID()
+	return o
+}
+
+// AsignarUUIDDeSolicitud devuelve una copia del error actual con el
+// identificador de la solicitud recibida (ver IdentificadorDeSolicitud) como
+// identificador único universal. Si la solicitud no posee identificador, se
+// genera un nuevo UUID.
+// El error actual no se modifica, por lo que puede utilizarse con errores
+// compartidos por distintas solicitudes (por ejemplo, errores declarados como
+// variables del paquete).
+func (o *errorAPIREST) AsignarUUIDDeSolicitud(r *http.Request) *errorAPIREST {
+	copia := *o
+	if copia.uuid = ObtenerIDDeSolicitud(r); copia.uuid == "" {
+		copia.uuid = generarUUID()
+	}
+
+	return &copia
+}
+
+// generarUUID genera un identificador único universal versión 4 (random).
 // 	https://es.wikipedia.org/wiki/Identificador_%C3%BAnico_universal
 // 	https://tools.ietf.org/html/rfc4122
 //
@@ -77,7 +129,7 @@ func (o *errorAPIREST) AsignarValoresAdicionales(valores ...string) *errorAPIRES
 // 		x es un valor hexadecimal (0, 1, 2... d, e, f).
 // 		M es un valor de 1 a 5 (versión del UUID): 4 es UUID random.
 // 		N es "8", "9", "a" o "b".
-func (o *errorAPIREST) AsignarUUID() *errorAPIREST {
+func generarUUID() string {
 	var a [16]byte
 	if _, err := io.ReadFull(randc.Reader, a[:]); err != nil {
 		// si se produce un error, utilizar otro método de generación.
@@ -100,8 +152,16 @@ func (o *errorAPIREST) AsignarUUID() *errorAPIREST {
 	for _, i := range []int{8, 13, 18, 23} {
 		b[i] = '-'
 	}
-	o.uuid = string(b)
 
+	return string(b)
+}
+
+// AsignarReintentarDespues asigna el tiempo que el cliente debe esperar antes
+// de reintentar el requerimiento. Al responder el error, el tiempo se informa
+// en segundos en el campo de cabecera "Retry-After" (utilizado principalmente
+// con los errores 429 y 503).
+func (o *errorAPIREST) AsignarReintentarDespues(duracion time.Duration) *errorAPIREST {
+	o.reintentarDespues = duracion
 	return o
 }
 
@@ -136,6 +196,12 @@ func (o *errorAPIREST) ObtenerValoresAdicionales() []string {
 	return o.valoresAdicionales
 }
 
+// ObtenerReintentarDespues devuelve el tiempo que el cliente debe esperar antes
+// de reintentar el requerimiento.
+func (o *errorAPIREST) ObtenerReintentarDespues() time.Duration {
+	return o.reintentarDespues
+}
+
 // ObtenerRastro devuelve el ratro, sólo del error actual.
 func (o *errorAPIREST) ObtenerRastro() *errorRastro {
 	return &o.rastro
@@ -166,17 +232,29 @@ func errorNuevo(estadoHTTP HTTPEstado, formato string, args ...interface{}) *err
 	return err
 }
 
+func errorEnvolver(err error, estadoHTTP HTTPEstado, formato string, args ...interface{}) *errorAPIREST {
+	errAPIREST := &errorAPIREST{
+		estadoHTTP:  estadoHTTP,
+		mensaje:     fmt.Sprintf(formato, args...),
+		errAnterior: err,
+	}
+	errAPIREST.asignarRastro(2)
+
+	return errAPIREST
+}
+
+// errorBuscarTipo recorre la cadena de errores (incluidos los errores
+// envueltos que no son de tipo errorAPIREST) y devuelve el primer error de
+// tipo errorAPIREST con el código de estado HTTP recibido.
 func errorBuscarTipo(err error, estadoHTTP HTTPEstado) (*errorAPIREST, bool) {
-	for {
+	for ; err != nil; err = errors.Unwrap(err) {
 		errAPIREST, ok := err.(*errorAPIREST)
-		if !ok {
-			return nil, false
-		}
-		if errAPIREST.estadoHTTP == estadoHTTP {
+		if ok && errAPIREST.estadoHTTP == estadoHTTP {
 			return errAPIREST, true
 		}
-		err = errAPIREST.errAnterior
 	}
+
+	return nil, false
 }
 
 // -----------------------------------------------------------------------------
@@ -184,17 +262,17 @@ func errorBuscarTipo(err error, estadoHTTP HTTPEstado) (*errorAPIREST, bool) {
 
 // ErrorEsAPIREST busca y verifica en la cadena de errores internos y devuelve
 // el error cuando encuentra un tipo de error determinado (estadoHTTP con valor).
+// La cadena se recorre también a través de los errores envueltos que no son de
+// tipo errorAPIREST (por ejemplo, fmt.Errorf("...: %w", err)).
 func ErrorEsAPIREST(err error) (*errorAPIREST, bool) {
-	for {
+	for ; err != nil; err = errors.Unwrap(err) {
 		errAPIREST, ok := err.(*errorAPIREST)
-		if !ok {
-			return nil, false
-		}
-		if errAPIREST.estadoHTTP.obtenerEntero() != 0 {
+		if ok && errAPIREST.estadoHTTP.obtenerEntero() != 0 {
 			return errAPIREST, true
 		}
-		err = errAPIREST.errAnterior
 	}
+
+	return nil, false
 }
 
 // -----------------------------------------------------------------------------
@@ -213,16 +291,14 @@ func ErrorNuevoRastro(err error) *errorAPIREST {
 
 // ErrorObtenerRastros devuelve la lista de rastros/ubicaciones por los que
 // pasó el error actual. Devuelve el trazado del error actual.
+// Los errores envueltos que no son de tipo errorAPIREST no poseen rastro, pero
+// la cadena se recorre a través de ellos.
 func ErrorObtenerRastros(err error) []errorRastro {
 	var rastros []errorRastro
-	for {
-		errAPIREST, ok := err.(*errorAPIREST)
-		if !ok {
-			break
+	for ; err != nil; err = errors.Unwrap(err) {
+		if errAPIREST, ok := err.(*errorAPIREST); ok {
+			rastros = append(rastros, errAPIREST.rastro)
 		}
-
-		rastros = append(rastros, errAPIREST.rastro)
-		err = errAPIREST.errAnterior
 	}
 
 	return rastros
@@ -237,6 +313,12 @@ func ErrorNuevoMalRequerimiento(formato string, args ...interface{}) *errorAPIRE
 	return errorNuevo(HTTPEstadoErrorMalRequerimiento, formato, args...)
 }
 
+// ErrorEnvolverMalRequerimiento crea un error de tipo:
+// 400 (Mal requerimiento), que envuelve al error recibido.
+func ErrorEnvolverMalRequerimiento(err error, formato string, args ...interface{}) *errorAPIREST {
+	return errorEnvolver(err, HTTPEstadoErrorMalRequerimiento, formato, args...)
+}
+
 // ErrorEsMalRequerimiento verifica que el error sea del tipo:
 // 400 (Mal requerimiento).
 func ErrorEsMalRequerimiento(err error) (*errorAPIREST, bool) {
@@ -252,6 +334,12 @@ func ErrorNuevoSinAutorizacion(formato string, args ...interface{}) *errorAPIRES
 	return errorNuevo(HTTPEstadoErrorSinAutorizacion, formato, args...)
 }
 
+// ErrorEnvolverSinAutorizacion crea un error de tipo:
+// 401 (Sin autorización), que envuelve al error recibido.
+func ErrorEnvolverSinAutorizacion(err error, formato string, args ...interface{}) *errorAPIREST {
+	return errorEnvolver(err, HTTPEstadoErrorSinAutorizacion, formato, args...)
+}
+
 // ErrorEsSinAutorizacion verifica que el error sea del tipo:
 // 401 (Sin autorización).
 func ErrorEsSinAutorizacion(err error) (*errorAPIREST, bool) {
@@ -267,6 +355,12 @@ func ErrorNuevoSinPrivilegios(formato string, args ...interface{}) *errorAPIREST
 	return errorNuevo(HTTPEstadoErrorSinPrivilegios, formato, args...)
 }
 
+// ErrorEnvolverSinPrivilegios crea un error de tipo:
+// 403 (Sin privilegios), que envuelve al error recibido.
+func ErrorEnvolverSinPrivilegios(err error, formato string, args ...interface{}) *errorAPIREST {
+	return errorEnvolver(err, HTTPEstadoErrorSinPrivilegios, formato, args...)
+}
+
 // ErrorEsSinPrivilegios verifica que el error sea del tipo:
 // 403 (Sin privilegios).
 func ErrorEsSinPrivilegios(err error) (*errorAPIREST, bool) {
@@ -282,6 +376,12 @@ func ErrorNuevoNoEncontrado(formato string, args ...interface{}) *errorAPIREST {
 	return errorNuevo(HTTPEstadoErrorNoEncontrado, formato, args...)
 }
 
+// ErrorEnvolverNoEncontrado crea un error de tipo:
+// 404 (No encontrado), que envuelve al error recibido.
+func ErrorEnvolverNoEncontrado(err error, formato string, args ...interface{}) *errorAPIREST {
+	return errorEnvolver(err, HTTPEstadoErrorNoEncontrado, formato, args...)
+}
+
 // ErrorEsNoEncontrado verifica que el error sea del tipo:
 // 404 (No encontrado).
 func ErrorEsNoEncontrado(err error) (*errorAPIREST, bool) {
@@ -297,12 +397,102 @@ func ErrorNuevoMetodoNoImplementado(formato string, args ...interface{}) *errorA
 	return errorNuevo(HTTPEstadoErrorMetodoNoImplementado, formato, args...)
 }
 
+// ErrorEnvolverMetodoNoImplementado crea un error de tipo:
+// 405 (Método no implementado), que envuelve al error recibido.
+func ErrorEnvolverMetodoNoImplementado(err error, formato string, args ...interface{}) *errorAPIREST {
+	return errorEnvolver(err, HTTPEstadoErrorMetodoNoImplementado, formato, args...)
+}
+
 // ErrorEsMetodoNoImplementado verifica que el error sea del tipo:
 // 405 (Método no implementado).
 func ErrorEsMetodoNoImplementado(err error) (*errorAPIREST, bool) {
 	return errorBuscarTipo(err, HTTPEstadoErrorMetodoNoImplementado)
 }
 
+// -----------------------------------------------------------------------------
+// Error no aceptable
+
+// ErrorNuevoNoAceptable crea un error de tipo:
+// 406 (No aceptable).
+func ErrorNuevoNoAceptable(formato string, args ...interface{}) *errorAPIREST {
+	return errorNuevo(HTTPEstadoErrorNoAceptable, formato, args...)
+}
+
+// ErrorEnvolverNoAceptable crea un error de tipo:
+// 406 (No aceptable), que envuelve al error recibido.
+func ErrorEnvolverNoAceptable(err error, formato string, args ...interface{}) *errorAPIREST {
+	return errorEnvolver(err, HTTPEstadoErrorNoAceptable, formato, args...)
+}
+
+// ErrorEsNoAceptable verifica que el error sea del tipo:
+// 406 (No aceptable).
+func ErrorEsNoAceptable(err error) (*errorAPIREST, bool) {
+	return errorBuscarTipo(err, HTTPEstadoErrorNoAceptable)
+}
+
+// -----------------------------------------------------------------------------
+// Error conflicto
+
+// ErrorNuevoConflicto crea un error de tipo:
+// 409 (Conflicto).
+func ErrorNuevoConflicto(formato string, args ...interface{}) *errorAPIREST {
+	return errorNuevo(HTTPEstadoErrorConflicto, formato, args...)
+}
+
+// ErrorEnvolverConflicto crea un error de tipo:
+// 409 (Conflicto), que envuelve al error recibido.
+func ErrorEnvolverConflicto(err error, formato string, args ...interface{}) *errorAPIREST {
+	return errorEnvolver(err, HTTPEstadoErrorConflicto, formato, args...)
+}
+
+// ErrorEsConflicto verifica que el error sea del tipo:
+// 409 (Conflicto).
+func ErrorEsConflicto(err error) (*errorAPIREST, bool) {
+	return errorBuscarTipo(err, HTTPEstadoErrorConflicto)
+}
+
+// -----------------------------------------------------------------------------
+// Error ya no disponible
+
+// ErrorNuevoYaNoDisponible crea un error de tipo:
+// 410 (Ya no disponible).
+func ErrorNuevoYaNoDisponible(formato string, args ...interface{}) *errorAPIREST {
+	return errorNuevo(HTTPEstadoErrorYaNoDisponible, formato, args...)
+}
+
+// ErrorEnvolverYaNoDisponible crea un error de tipo:
+// 410 (Ya no disponible), que envuelve al error recibido.
+func ErrorEnvolverYaNoDisponible(err error, formato string, args ...interface{}) *errorAPIREST {
+	return errorEnvolver(err, HTTPEstadoErrorYaNoDisponible, formato, args...)
+}
+
+// ErrorEsYaNoDisponible verifica que el error sea del tipo:
+// 410 (Ya no disponible).
+func ErrorEsYaNoDisponible(err error) (*errorAPIREST, bool) {
+	return errorBuscarTipo(err, HTTPEstadoErrorYaNoDisponible)
+}
+
+// -----------------------------------------------------------------------------
+// Error precondición fallida
+
+// ErrorNuevoPrecondicionFallida crea un error de tipo:
+// 412 (Precondición fallida).
+func ErrorNuevoPrecondicionFallida(formato string, args ...interface{}) *errorAPIREST {
+	return errorNuevo(HTTPEstadoErrorPrecondicionFallida, formato, args...)
+}
+
+// ErrorEnvolverPrecondicionFallida crea un error de tipo:
+// 412 (Precondición fallida), que envuelve al error recibido.
+func ErrorEnvolverPrecondicionFallida(err error, formato string, args ...interface{}) *errorAPIREST {
+	return errorEnvolver(err, HTTPEstadoErrorPrecondicionFallida, formato, args...)
+}
+
+// ErrorEsPrecondicionFallida verifica que el error sea del tipo:
+// 412 (Precondición fallida).
+func ErrorEsPrecondicionFallida(err error) (*errorAPIREST, bool) {
+	return errorBuscarTipo(err, HTTPEstadoErrorPrecondicionFallida)
+}
+
 // -----------------------------------------------------------------------------
 // Error requerimiento muy grande
 
@@ -312,6 +502,12 @@ func ErrorNuevoRequerimientoMuyGrande(formato string, args ...interface{}) *erro
 	return errorNuevo(HTTPEstadoErrorRequerimientoMuyGrande, formato, args...)
 }
 
+// ErrorEnvolverRequerimientoMuyGrande crea un error de tipo:
+// 413 (Requerimiento muy grande), que envuelve al error recibido.
+func ErrorEnvolverRequerimientoMuyGrande(err error, formato string, args ...interface{}) *errorAPIREST {
+	return errorEnvolver(err, HTTPEstadoErrorRequerimientoMuyGrande, formato, args...)
+}
+
 // ErrorEsRequerimientoMuyGrande verifica que el error sea del tipo:
 // 413 (Requerimiento muy grande).
 func ErrorEsRequerimientoMuyGrande(err error) (*errorAPIREST, bool) {
@@ -327,6 +523,12 @@ func ErrorNuevoURIMuyGrande(formato string, args ...interface{}) *errorAPIREST {
 	return errorNuevo(HTTPEstadoErrorURIMuyGrande, formato, args...)
 }
 
+// ErrorEnvolverURIMuyGrande crea un error de tipo:
+// 414 (URI muy grande), que envuelve al error recibido.
+func ErrorEnvolverURIMuyGrande(err error, formato string, args ...interface{}) *errorAPIREST {
+	return errorEnvolver(err, HTTPEstadoErrorURIMuyGrande, formato, args...)
+}
+
 // ErrorEsURIMuyGrande verifica que el error sea del tipo:
 // 414 (URI muy grande).
 func ErrorEsURIMuyGrande(err error) (*errorAPIREST, bool) {
@@ -342,12 +544,81 @@ func ErrorNuevoMalFormato(formato string, args ...interface{}) *errorAPIREST {
 	return errorNuevo(HTTPEstadoErrorMalFormato, formato, args...)
 }
 
+// ErrorEnvolverMalFormato crea un error de tipo:
+// 415 (mal formato), que envuelve al error recibido.
+func ErrorEnvolverMalFormato(err error, formato string, args ...interface{}) *errorAPIREST {
+	return errorEnvolver(err, HTTPEstadoErrorMalFormato, formato, args...)
+}
+
 // ErrorEsMalFormato verifica que el error sea del tipo:
 // 415 (mal formato).
 func ErrorEsMalFormato(err error) (*errorAPIREST, bool) {
 	return errorBuscarTipo(err, HTTPEstadoErrorMalFormato)
 }
 
+// -----------------------------------------------------------------------------
+// Error entidad no procesable
+
+// ErrorNuevoEntidadNoProcesable crea un error de tipo:
+// 422 (Entidad no procesable).
+func ErrorNuevoEntidadNoProcesable(formato string, args ...interface{}) *errorAPIREST {
+	return errorNuevo(HTTPEstadoErrorEntidadNoProcesable, formato, args...)
+}
+
+// ErrorEnvolverEntidadNoProcesable crea un error de tipo:
+// 422 (Entidad no procesable), que envuelve al error recibido.
+func ErrorEnvolverEntidadNoProcesable(err error, formato string, args ...interface{}) *errorAPIREST {
+	return errorEnvolver(err, HTTPEstadoErrorEntidadNoProcesable, formato, args...)
+}
+
+// ErrorEsEntidadNoProcesable verifica que el error sea del tipo:
+// 422 (Entidad no procesable).
+func ErrorEsEntidadNoProcesable(err error) (*errorAPIREST, bool) {
+	return errorBuscarTipo(err, HTTPEstadoErrorEntidadNoProcesable)
+}
+
+// -----------------------------------------------------------------------------
+// Error precondición requerida
+
+// ErrorNuevoPrecondicionRequerida crea un error de tipo:
+// 428 (Precondición requerida).
+func ErrorNuevoPrecondicionRequerida(formato string, args ...interface{}) *errorAPIREST {
+	return errorNuevo(HTTPEstadoErrorPrecondicionRequerida, formato, args...)
+}
+
+// ErrorEnvolverPrecondicionRequerida crea un error de tipo:
+// 428 (Precondición requerida), que envuelve al error recibido.
+func ErrorEnvolverPrecondicionRequerida(err error, formato string, args ...interface{}) *errorAPIREST {
+	return errorEnvolver(err, HTTPEstadoErrorPrecondicionRequerida, formato, args...)
+}
+
+// ErrorEsPrecondicionRequerida verifica que el error sea del tipo:
+// 428 (Precondición requerida).
+func ErrorEsPrecondicionRequerida(err error) (*errorAPIREST, bool) {
+	return errorBuscarTipo(err, HTTPEstadoErrorPrecondicionRequerida)
+}
+
+// -----------------------------------------------------------------------------
+// Error demasiados requerimientos
+
+// ErrorNuevoDemasiadosRequerimientos crea un error de tipo:
+// 429 (Demasiados requerimientos).
+func ErrorNuevoDemasiadosRequerimientos(formato string, args ...interface{}) *errorAPIREST {
+	return errorNuevo(HTTPEstadoErrorDemasiadosRequerimientos, formato, args...)
+}
+
+// ErrorEnvolverDemasiadosRequerimientos crea un error de tipo:
+// 429 (Demasiados requerimientos), que envuelve al error recibido.
+func ErrorEnvolverDemasiadosRequerimientos(err error, formato string, args ...interface{}) *errorAPIREST {
+	return errorEnvolver(err, HTTPEstadoErrorDemasiadosRequerimientos, formato, args...)
+}
+
+// ErrorEsDemasiadosRequerimientos verifica que el error sea del tipo:
+// 429 (Demasiados requerimientos).
+func ErrorEsDemasiadosRequerimientos(err error) (*errorAPIREST, bool) {
+	return errorBuscarTipo(err, HTTPEstadoErrorDemasiadosRequerimientos)
+}
+
 // -----------------------------------------------------------------------------
 // Error interno del servidor
 
@@ -357,8 +628,77 @@ func ErrorNuevoInternoDeServidor(formato string, args ...interface{}) *errorAPIR
 	return errorNuevo(HTTPEstadoErrorInternoDeServidor, formato, args...)
 }
 
+// ErrorEnvolverInternoDeServidor crea un error de tipo:
+// 500 (error interno del servidor), que envuelve al error recibido.
+func ErrorEnvolverInternoDeServidor(err error, formato string, args ...interface{}) *errorAPIREST {
+	return errorEnvolver(err, HTTPEstadoErrorInternoDeServidor, formato, args...)
+}
+
 // ErrorEsInternoDeServidor verifica que el error sea del tipo:
 // 500 (error interno del servidor).
 func ErrorEsInternoDeServidor(err error) (*errorAPIREST, bool) {
 	return errorBuscarTipo(err, HTTPEstadoErrorInternoDeServidor)
 }
+
+// -----------------------------------------------------------------------------
+// Error puerta de enlace incorrecta
+
+// ErrorNuevoPuertaDeEnlaceIncorrecta crea un error de tipo:
+// 502 (Puerta de enlace incorrecta).
+func ErrorNuevoPuertaDeEnlaceIncorrecta(formato string, args ...interface{}) *errorAPIREST {
+	return errorNuevo(HTTPEstadoErrorPuertaDeEnlaceIncorrecta, formato, args...)
+}
+
+// ErrorEnvolverPuertaDeEnlaceIncorrecta crea un error de tipo:
+// 502 (Puerta de enlace incorrecta), que envuelve al error recibido.
+func ErrorEnvolverPuertaDeEnlaceIncorrecta(err error, formato string, args ...interface{}) *errorAPIREST {
+	return errorEnvolver(err, HTTPEstadoErrorPuertaDeEnlaceIncorrecta, formato, args...)
+}
+
+// ErrorEsPuertaDeEnlaceIncorrecta verifica que el error sea del tipo:
+// 502 (Puerta de enlace incorrecta).
+func ErrorEsPuertaDeEnlaceIncorrecta(err error) (*errorAPIREST, bool) {
+	return errorBuscarTipo(err, HTTPEstadoErrorPuertaDeEnlaceIncorrecta)
+}
+
+// -----------------------------------------------------------------------------
+// Error servicio no disponible
+
+// ErrorNuevoServicioNoDisponible crea un error de tipo:
+// 503 (Servicio no disponible).
+func ErrorNuevoServicioNoDisponible(formato string, args ...interface{}) *errorAPIREST {
+	return errorNuevo(HTTPEstadoErrorServicioNoDisponible, formato, args...)
+}
+
+// ErrorEnvolverServicioNoDisponible crea un error de tipo:
+// 503 (Servicio no disponible), que envuelve al error recibido.
+func ErrorEnvolverServicioNoDisponible(err error, formato string, args ...interface{}) *errorAPIREST {
+	return errorEnvolver(err, HTTPEstadoErrorServicioNoDisponible, formato, args...)
+}
+
+// ErrorEsServicioNoDisponible verifica que el error sea del tipo:
+// 503 (Servicio no disponible).
+func ErrorEsServicioNoDisponible(err error) (*errorAPIREST, bool) {
+	return errorBuscarTipo(err, HTTPEstadoErrorServicioNoDisponible)
+}
+
+// -----------------------------------------------------------------------------
+// Error tiempo de puerta de enlace agotado
+
+// ErrorNuevoTiempoDePuertaDeEnlaceAgotado crea un error de tipo:
+// 504 (Tiempo de puerta de enlace agotado).
+func ErrorNuevoTiempoDePuertaDeEnlaceAgotado(formato string, args ...interface{}) *errorAPIREST {
+	return errorNuevo(HTTPEstadoErrorTiempoDePuertaDeEnlaceAgotado, formato, args...)
+}
+
+// ErrorEnvolverTiempoDePuertaDeEnlaceAgotado crea un error de tipo:
+// 504 (Tiempo de puerta de enlace agotado), que envuelve al error recibido.
+func ErrorEnvolverTiempoDePuertaDeEnlaceAgotado(err error, formato string, args ...interface{}) *errorAPIREST {
+	return errorEnvolver(err, HTTPEstadoErrorTiempoDePuertaDeEnlaceAgotado, formato, args...)
+}
+
+// ErrorEsTiempoDePuertaDeEnlaceAgotado verifica que el error sea del tipo:
+// 504 (Tiempo de puerta de enlace agotado).
+func ErrorEsTiempoDePuertaDeEnlaceAgotado(err error) (*errorAPIREST, bool) {
+	return errorBuscarTipo(err, HTTPEstadoErrorTiempoDePuertaDeEnlaceAgotado)
+}
@@ -0,0 +1,142 @@
+package apirest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// constructorDeError agrupa las funciones de un tipo de error.
+type constructorDeError struct {
+	estado   int
+	nuevo    func(formato string, args ...interface{}) *errorAPIREST
+	envolver func(err error, formato string, args ...interface{}) *errorAPIREST
+	es       func(err error) (*errorAPIREST, bool)
+}
+
+var constructoresDeError = []constructorDeError{
+	{http.StatusBadRequest, ErrorNuevoMalRequerimiento, ErrorEnvolverMalRequerimiento, ErrorEsMalRequerimiento},
+	{http.StatusUnauthorized, ErrorNuevoSinAutorizacion, ErrorEnvolverSinAutorizacion, ErrorEsSinAutorizacion},
+	{http.StatusForbidden, ErrorNuevoSinPrivilegios, ErrorEnvolverSinPrivilegios, ErrorEsSinPrivilegios},
+	{http.StatusNotFound, ErrorNuevoNoEncontrado, ErrorEnvolverNoEncontrado, ErrorEsNoEncontrado},
+	{http.StatusMethodNotAllowed, ErrorNuevoMetodoNoImplementado, ErrorEnvolverMetodoNoImplementado, ErrorEsMetodoNoImplementado},
+	{http.StatusRequestEntityTooLarge, ErrorNuevoRequerimientoMuyGrande, ErrorEnvolverRequerimientoMuyGrande, ErrorEsRequerimientoMuyGrande},
+	{http.StatusRequestURITooLong, ErrorNuevoURIMuyGrande, ErrorEnvolverURIMuyGrande, ErrorEsURIMuyGrande},
+	{http.StatusUnsupportedMediaType, ErrorNuevoMalFormato, ErrorEnvolverMalFormato, ErrorEsMalFormato},
+	{http.StatusInternalServerError, ErrorNuevoInternoDeServidor, ErrorEnvolverInternoDeServidor, ErrorEsInternoDeServidor},
+	{http.StatusNotAcceptable, ErrorNuevoNoAceptable, ErrorEnvolverNoAceptable, ErrorEsNoAceptable},
+	{http.StatusConflict, ErrorNuevoConflicto, ErrorEnvolverConflicto, ErrorEsConflicto},
+	{http.StatusGone, ErrorNuevoYaNoDisponible, ErrorEnvolverYaNoDisponible, ErrorEsYaNoDisponible},
+	{http.StatusPreconditionFailed, ErrorNuevoPrecondicionFallida, ErrorEnvolverPrecondicionFallida, ErrorEsPrecondicionFallida},
+	{http.StatusUnprocessableEntity, ErrorNuevoEntidadNoProcesable, ErrorEnvolverEntidadNoProcesable, ErrorEsEntidadNoProcesable},
+	{http.StatusPreconditionRequired, ErrorNuevoPrecondicionRequerida, ErrorEnvolverPrecondicionRequerida, ErrorEsPrecondicionRequerida},
+	{http.StatusTooManyRequests, ErrorNuevoDemasiadosRequerimientos, ErrorEnvolverDemasiadosRequerimientos, ErrorEsDemasiadosRequerimientos},
+	{http.StatusBadGateway, ErrorNuevoPuertaDeEnlaceIncorrecta, ErrorEnvolverPuertaDeEnlaceIncorrecta, ErrorEsPuertaDeEnlaceIncorrecta},
+	{http.StatusServiceUnavailable, ErrorNuevoServicioNoDisponible, ErrorEnvolverServicioNoDisponible, ErrorEsServicioNoDisponible},
+	{http.StatusGatewayTimeout, ErrorNuevoTiempoDePuertaDeEnlaceAgotado, ErrorEnvolverTiempoDePuertaDeEnlaceAgotado, ErrorEsTiempoDePuertaDeEnlaceAgotado},
+}
+
+func TestConstructoresDeError(t *testing.T) {
+	errOriginal := errors.New("original")
+
+	for _, c := range constructoresDeError {
+		t.Run(fmt.Sprint(c.estado), func(t *testing.T) {
+			err := c.nuevo("mensaje %d", 1)
+			if err.ObtenerHTTPEstado().obtenerEntero() != c.estado || err.Error() != "mensaje 1" || err.Unwrap() != nil {
+				t.Errorf("nuevo: se obtuvo %v %q", err.ObtenerHTTPEstado(), err.Error())
+			}
+			if err.ObtenerRastro().Archivo != "errores_test" {
+				t.Errorf("nuevo: el rastro apunta a %+v", err.ObtenerRastro())
+			}
+
+			envuelto := c.envolver(errOriginal, "mensaje %d", 2)
+			if envuelto.ObtenerHTTPEstado().obtenerEntero() != c.estado || envuelto.Error() != "mensaje 2" ||
+				!errors.Is(envuelto, errOriginal) || errors.Unwrap(envuelto) != errOriginal {
+				t.Errorf("envolver: se obtuvo %v %q", envuelto.ObtenerHTTPEstado(), envuelto.Error())
+			}
+			if envuelto.ObtenerRastro().Archivo != "errores_test" {
+				t.Errorf("envolver: el rastro apunta a %+v", envuelto.ObtenerRastro())
+			}
+
+			// ErrorEsX encuentra el error a través de errores envueltos de otro tipo
+			if e, ok := c.es(fmt.Errorf("capa: %w", envuelto)); !ok || e != envuelto {
+				t.Errorf("es: no se encontró el error envuelto")
+			}
+			if _, ok := c.es(errOriginal); ok {
+				t.Errorf("es: se encontró el error en un error de otro tipo")
+			}
+		})
+	}
+}
+
+func TestErrorEsConOtroEstado(t *testing.T) {
+	err := fmt.Errorf("capa: %w", ErrorEnvolverNoEncontrado(ErrorNuevoMalRequerimiento("interno"), "externo"))
+
+	// se devuelve el error con el estado buscado, aunque no sea el primero
+	if e, ok := ErrorEsMalRequerimiento(err); !ok || e.Error() != "interno" {
+		t.Errorf("se obtuvo %v %v", e, ok)
+	}
+	if e, ok := ErrorEsAPIREST(err); !ok || e.Error() != "externo" {
+		t.Errorf("ErrorEsAPIREST devolvió %v %v", e, ok)
+	}
+	if _, ok := ErrorEsSinAutorizacion(err); ok {
+		t.Errorf("se encontró un error inexistente")
+	}
+}
+
+func TestErrorIs(t *testing.T) {
+	err := fmt.Errorf("capa: %w", ErrorNuevoNoEncontrado("La persona no existe").AsignarCodigo("personas.inexistente"))
+
+	pruebas := []struct {
+		objetivo error
+		esperado bool
+	}{
+		{ErrorNuevoNoEncontrado(""), true},
+		{ErrorNuevoNoEncontrado("").AsignarCodigo("personas.inexistente"), true},
+		{&errorAPIREST{codigo: "personas.inexistente"}, true},
+		{ErrorNuevoNoEncontrado("").AsignarCodigo("otro"), false},
+		{ErrorNuevoConflicto("").AsignarCodigo("personas.inexistente"), false},
+		{&errorAPIREST{}, false},
+		{errors.New("La persona no existe"), false},
+	}
+	for i, p := range pruebas {
+		if errors.Is(err, p.objetivo) != p.esperado {
+			t.Errorf("%d: errors.Is(%v) se esperaba %v", i, p.objetivo, p.esperado)
+		}
+	}
+}
+
+func TestErrorAs(t *testing.T) {
+	original := ErrorNuevoConflicto("Ya existe")
+	err := fmt.Errorf("capa: %w", ErrorNuevoRastro(original))
+
+	var errAPIREST *errorAPIREST
+	if !errors.As(err, &errAPIREST) || errors.Unwrap(errAPIREST) != original {
+		t.Errorf("errors.As devolvió %v", errAPIREST)
+	}
+}
+
+func TestErrorNuevoRastro(t *testing.T) {
+	original := errors.New("sin conexión")
+	err := ErrorNuevoRastro(fmt.Errorf("capa: %w", ErrorEnvolverInternoDeServidor(ErrorNuevoRastro(original), "Error interno")))
+
+	// el rastro sin mensaje utiliza el mensaje del error anterior
+	if err.Error() != "capa: Error interno" {
+		t.Errorf("se obtuvo %q", err.Error())
+	}
+	if _, ok := ErrorEsAPIREST(ErrorNuevoRastro(original)); ok {
+		t.Errorf("un rastro sin estado no es un error con código de estado")
+	}
+
+	// los rastros se obtienen a través de los errores de otro tipo
+	rastros := ErrorObtenerRastros(err)
+	if len(rastros) != 3 {
+		t.Fatalf("se obtuvieron %d rastros: %+v", len(rastros), rastros)
+	}
+	for _, rastro := range rastros {
+		if rastro.Archivo != "errores_test" || rastro.Funcion == "" || rastro.NroLinea == 0 {
+			t.Errorf("rastro inválido: %+v", rastro)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package apirest
+
+import "strings"
+
+// Grupo agrupa endpoints que comparten un prefijo de ruta, una lista de
+// interceptores (middlewares) y los campos CORS requeridos y expuestos.
+// Los grupos pueden anidarse: un grupo hijo hereda el prefijo, los
+// interceptores y los campos CORS de su grupo padre.
+// Ejemplo:
+// 	r.Grupo("/v1", func(v1 *apirest.Grupo) {
+// 		v1.Interceptores(autenticar())
+// 		v1.Grupo("/personas", func(g *apirest.Grupo) {
+// 			g.CORSCamposRequeridos("Authorization")
+// 			g.GET("", listarPersonas)
+// 			g.GET("/{id:int}", obtenerPersona)
+// 		})
+// 	})
+type Grupo struct {
+	enrutador     *enrutador        // enrutador en el cuál se registran los endpoints
+	padre         *Grupo            // grupo padre (es nulo para los grupos creados desde el enrutador)
+	prefijo       string            // prefijo de ruta del grupo (incluye el prefijo del grupo padre)
+	interceptores []InterceptorFunc // interceptores (middlewares) propios del grupo
+	endpoints     []*endpoint       // endpoints creados en el grupo
+	hijos         []*Grupo          // grupos anidados
+
+	cors struct {
+		camposRequeridos []string // campos CORS requeridos por todos los endpoints del grupo
+		camposExpuestos  []string // campos CORS expuestos por todos los endpoints del grupo
+	}
+}
+
+// Grupo crea un grupo de endpoints con el prefijo de ruta recibido. La función
+// configurar recibe el grupo para crear sus endpoints, interceptores y grupos
+// anidados.
+func (o *enrutador) Grupo(prefijo string, configurar func(g *Grupo)) *Grupo {
+	var g = &Grupo{enrutador: o, prefijo: unirRutas("", prefijo)}
+	if configurar != nil {
+		configurar(g)
+	}
+
+	return g
+}
+
+// Grupo crea un grupo anidado, cuyo prefijo de ruta se agrega al prefijo del
+// grupo actual. El grupo anidado hereda los interceptores y los campos CORS
+// del grupo actual.
+func (o *Grupo) Grupo(prefijo string, configurar func(g *Grupo)) *Grupo {
+	var g = &Grupo{enrutador: o.enrutador, padre: o, prefijo: unirRutas(o.prefijo, prefijo)}
+	o.hijos = append(o.hijos, g)
+	if configurar != nil {
+		configurar(g)
+	}
+
+	return g
+}
+
+// Interceptores agrega interceptores (middlewares) a todos los endpoints del
+// grupo y de sus grupos anidados. Los interceptores de los grupos padres se
+// procesan antes que los del grupo actual.
+func (o *Grupo) Interceptores(funciones ...InterceptorFunc) *Grupo {
+	o.interceptores = append(o.interceptores, funciones...)
+	o.recorrerEndpoints(func(ep *endpoint) {
+		ep.encadenar()
+	})
+
+	return o
+}
+
+// CORSCamposRequeridos solicita los campos CORS requeridos para poder procesar
+// todos los endpoints del grupo y de sus grupos anidados.
+// es el campo de cabecera: "Access-Control-Allow-Headers"
+func (o *Grupo) CORSCamposRequeridos(campos ...string) *Grupo {
+	o.cors.camposRequeridos = append(o.cors.camposRequeridos, campos...)
+	o.recorrerEndpoints(func(ep *endpoint) {
+		ep.CORSCamposRequeridos(campos...)
+	})
+
+	return o
+}
+
+// CORSCamposExpuestos establece los campos CORS que expondrán todos los
+// endpoints del grupo y de sus grupos anidados.
+// es el campo de cabecera: "Access-Control-Expose-Headers"
+func (o *Grupo) CORSCamposExpuestos(campos ...string) *Grupo {
+	o.cors.camposExpuestos = append(o.cors.camposExpuestos, campos...)
+	o.recorrerEndpoints(func(ep *endpoint) {
+		ep.CORSCamposExpuestos(campos...)
+	})
+
+	return o
+}
+
+// Manejar gestiona las operaciones de cualquier método HTTP, incluidos los
+// métodos no estándar (PROPFIND, QUERY, ...).
+func (o *Grupo) Manejar(metodo, ruta string, funcion ManejadorFunc) *endpoint {
+	return o.nuevoEndpoint(metodo, ruta, funcion)
+}
+
+// GET gestiona las operaciones GET de HTTP (consulta de recursos).
+func (o *Grupo) GET(ruta string, funcion ManejadorFunc) *endpoint {
+	return o.nuevoEndpoint("GET", ruta, funcion)
+}
+
+// POST gestiona las operaciones POST de HTTP (nuevos recursos).
+func (o *Grupo) POST(ruta string, funcion ManejadorFunc) *endpoint {
+	return o.nuevoEndpoint("POST", ruta, funcion)
+}
+
+// PUT gestiona las operaciones PUT de HTTP (modificacion completa de recursos).
+func (o *Grupo) PUT(ruta string, funcion ManejadorFunc) *endpoint {
+	return o.nuevoEndpoint("PUT", ruta, funcion)
+}
+
+// PATCH gestiona las operaciones PATCH de HTTP (modificacion parcial de recursos).
+func (o *Grupo) PATCH(ruta string, funcion ManejadorFunc) *endpoint {
+	return o.nuevoEndpoint("PATCH", ruta, funcion)
+}
+
+// DELETE gestiona las operaciones DELETE de HTTP (eliminación de recursos).
+func (o *Grupo) DELETE(ruta string, funcion ManejadorFunc) *endpoint {
+	return o.nuevoEndpoint("DELETE", ruta, funcion)
+}
+
+// nuevoEndpoint crea el endpoint en el enrutador, anteponiendo el prefijo del
+// grupo a la ruta y asignando los interceptores y campos CORS heredados.
+func (o *Grupo) nuevoEndpoint(metodo string, ruta string, funcion ManejadorFunc) *endpoint {
+	ep := o.enrutador.nuevoEndpoint(metodo, unirRutas(o.prefijo, ruta), funcion)
+	ep.grupo = o
+	ep.encadenar()
+	for g := o; g != nil; g = g.padre {
+		ep.CORSCamposRequeridos(g.cors.camposRequeridos...)
+		ep.CORSCamposExpuestos(g.cors.camposExpuestos...)
+	}
+	o.endpoints = append(o.endpoints, ep)
+
+	return ep
+}
+
+// obtenerInterceptores devuelve los interceptores del grupo, precedidos por
+// los interceptores de sus grupos padres.
+func (o *Grupo) obtenerInterceptores() []InterceptorFunc {
+	if o.padre == nil {
+		return append([]InterceptorFunc(nil), o.interceptores...)
+	}
+
+	return append(o.padre.obtenerInterceptores(), o.interceptores...)
+}
+
+// recorrerEndpoints ejecuta la función recibida por cada endpoint del grupo y
+// de sus grupos anidados.
+func (o *Grupo) recorrerEndpoints(f func(ep *endpoint)) {
+	for _, ep := range o.endpoints {
+		f(ep)
+	}
+	for _, hijo := range o.hijos {
+		hijo.recorrerEndpoints(f)
+	}
+}
+
+// unirRutas une el prefijo de un grupo con la ruta de un endpoint:
+// unirRutas("/v1/", "/personas") devuelve "/v1/personas".
+func unirRutas(prefijo, ruta string) string {
+	prefijo = strings.Trim(prefijo, "/ ")
+	ruta = strings.Trim(ruta, "/ ")
+
+	switch {
+	case prefijo == "" && ruta == "":
+		return "/"
+	case prefijo == "":
+		return "/" + ruta
+	case ruta == "":
+		return "/" + prefijo
+	}
+
+	return "/" + prefijo + "/" + ruta
+}
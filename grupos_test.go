@@ -0,0 +1,110 @@
+package apirest
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// interceptorMarcador devuelve un interceptor que agrega su marca a la lista
+// recibida, para verificar el orden en que se procesan los interceptores.
+func interceptorMarcador(marcas *[]string, marca string) InterceptorFunc {
+	return func(manejadorFunc ManejadorFunc) ManejadorFunc {
+		return func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+			*marcas = append(*marcas, marca)
+			return manejadorFunc(w, r)
+		}
+	}
+}
+
+func TestGrupos(t *testing.T) {
+	var marcas []string
+	manejador := func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		marcas = append(marcas, "manejador")
+		return ObtenerVariablesDeRuta(r), nil
+	}
+
+	r := CrearEnrutador()
+	r.Grupo("/v1/", func(v1 *Grupo) {
+		v1.Interceptores(interceptorMarcador(&marcas, "v1"))
+		v1.GET("/estado", manejador)
+		v1.Grupo("personas", func(g *Grupo) {
+			g.Interceptores(interceptorMarcador(&marcas, "personas"))
+			g.GET("", manejador)
+			g.GET("/{id:int}", manejador).Interceptores(interceptorMarcador(&marcas, "endpoint"))
+		})
+	})
+
+	pruebas := []struct {
+		ruta   string
+		marcas []string
+	}{
+		{"/v1/estado", []string{"v1", "manejador"}},
+		{"/v1/personas", []string{"v1", "personas", "manejador"}},
+		{"/v1/personas/5", []string{"v1", "personas", "endpoint", "manejador"}},
+	}
+	for _, p := range pruebas {
+		marcas = nil
+		if w := solicitar(r, "GET", p.ruta, nil); w.Code != http.StatusOK {
+			t.Errorf("%s: se obtuvo %d", p.ruta, w.Code)
+		}
+		if !reflect.DeepEqual(marcas, p.marcas) {
+			t.Errorf("%s: se procesó %v, se esperaba %v", p.ruta, marcas, p.marcas)
+		}
+	}
+}
+
+func TestGrupoInterceptoresAgregadosDespues(t *testing.T) {
+	var marcas []string
+	r := CrearEnrutador()
+	v1 := r.Grupo("/v1", nil)
+	personas := v1.Grupo("/personas", nil)
+	personas.GET("", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, nil
+	})
+
+	// los interceptores agregados luego de crear los endpoints también se
+	// aplican, manteniendo el orden padre, hijo
+	personas.Interceptores(interceptorMarcador(&marcas, "personas"))
+	v1.Interceptores(interceptorMarcador(&marcas, "v1"))
+
+	solicitar(r, "GET", "/v1/personas", nil)
+	if esperado := []string{"v1", "personas"}; !reflect.DeepEqual(marcas, esperado) {
+		t.Errorf("se procesó %v, se esperaba %v", marcas, esperado)
+	}
+}
+
+func TestGrupoCamposCORS(t *testing.T) {
+	r := CrearEnrutador()
+	var ep *endpoint
+	v1 := r.Grupo("/v1", func(v1 *Grupo) {
+		v1.CORSCamposRequeridos("Authorization")
+		v1.Grupo("/personas", func(g *Grupo) {
+			g.CORSCamposExpuestos("X-Total")
+			ep = g.GET("", nil)
+		})
+	})
+	v1.CORSCamposRequeridos("X-Tenant")
+
+	if esperado := []string{"Authorization", "X-Tenant"}; !reflect.DeepEqual(ep.detalle.cors.camposRequeridos, esperado) {
+		t.Errorf("campos requeridos %v, se esperaba %v", ep.detalle.cors.camposRequeridos, esperado)
+	}
+	if esperado := []string{"X-Total"}; !reflect.DeepEqual(ep.detalle.cors.camposExpuestos, esperado) {
+		t.Errorf("campos expuestos %v, se esperaba %v", ep.detalle.cors.camposExpuestos, esperado)
+	}
+}
+
+func TestUnirRutas(t *testing.T) {
+	pruebas := []struct{ prefijo, ruta, esperado string }{
+		{"", "", "/"},
+		{"/v1/", "", "/v1"},
+		{"", "personas", "/personas"},
+		{"/v1/", "/personas/", "/v1/personas"},
+		{"v1", "{id}", "/v1/{id}"},
+	}
+	for _, p := range pruebas {
+		if ruta := unirRutas(p.prefijo, p.ruta); ruta != p.esperado {
+			t.Errorf("unirRutas(%q, %q) = %q, se esperaba %q", p.prefijo, p.ruta, ruta, p.esperado)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package apirest
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestCrearInterceptoresOrden(t *testing.T) {
+	var marcas []string
+	final := func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		marcas = append(marcas, "final")
+		return nil, nil
+	}
+
+	f := CrearInterceptores(interceptorMarcador(&marcas, "a"), interceptorMarcador(&marcas, "b")).
+		Agregar(interceptorMarcador(&marcas, "c")).
+		Ejecutar(final)
+	f(nil, nil)
+
+	if esperado := []string{"a", "b", "c", "final"}; !reflect.DeepEqual(marcas, esperado) {
+		t.Errorf("se procesó %v, se esperaba %v", marcas, esperado)
+	}
+}
+
+func TestInterceptoresDelEnrutador(t *testing.T) {
+	var marcas []string
+	r := CrearEnrutador()
+	r.Usar(interceptorMarcador(&marcas, "a"))
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		marcas = append(marcas, "manejador")
+		return nil, nil
+	}).Interceptores(interceptorMarcador(&marcas, "endpoint"))
+
+	// los interceptores agregados luego de registrar los endpoints también
+	// se procesan
+	r.Usar(interceptorMarcador(&marcas, "b"))
+
+	solicitar(r, "GET", "/personas", nil)
+	if esperado := []string{"a", "b", "endpoint", "manejador"}; !reflect.DeepEqual(marcas, esperado) {
+		t.Errorf("se procesó %v, se esperaba %v", marcas, esperado)
+	}
+}
+
+func TestInterceptoresDelEnrutadorEnRespuestasDelEnrutador(t *testing.T) {
+	var marcas []string
+	r := CrearEnrutador()
+	r.Usar(interceptorMarcador(&marcas, "enrutador"))
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, nil
+	}).Interceptores(interceptorMarcador(&marcas, "endpoint"))
+
+	pruebas := []struct {
+		metodo, ruta string
+		estado       int
+		allow        string
+	}{
+		{"GET", "/inexistente", http.StatusNotFound, ""},
+		{"DELETE", "/personas", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS"},
+		{"OPTIONS", "/personas", http.StatusNoContent, "GET, HEAD, OPTIONS"},
+	}
+	for _, p := range pruebas {
+		marcas = nil
+		w := solicitar(r, p.metodo, p.ruta, nil)
+		if w.Code != p.estado || w.Header().Get("Allow") != p.allow {
+			t.Errorf("%s %s: se obtuvo %d %q, se esperaba %d %q", p.metodo, p.ruta, w.Code, w.Header().Get("Allow"), p.estado, p.allow)
+		}
+		if esperado := []string{"enrutador"}; !reflect.DeepEqual(marcas, esperado) {
+			t.Errorf("%s %s: se procesó %v, se esperaba %v", p.metodo, p.ruta, marcas, esperado)
+		}
+	}
+}
+
+func TestInterceptorRespondeError(t *testing.T) {
+	var procesado bool
+	r := CrearEnrutador()
+	r.Usar(func(manejadorFunc ManejadorFunc) ManejadorFunc {
+		return func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+			if r.Header.Get("Authorization") == "" {
+				return nil, ErrorNuevoSinAutorizacion("Sin credenciales").AsignarCodigo("prueba.sinCredenciales")
+			}
+			return manejadorFunc(w, r)
+		}
+	})
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		procesado = true
+		return "ok", nil
+	})
+
+	w := solicitar(r, "GET", "/personas", nil)
+	if c := decodificarCuerpoDeError(t, w); w.Code != http.StatusUnauthorized || c.Error.Codigo != "prueba.sinCredenciales" || procesado {
+		t.Errorf("se obtuvo %d %+v, procesado: %v", w.Code, c.Error, procesado)
+	}
+
+	w = solicitar(r, "GET", "/personas", map[string]string{"Authorization": "Bearer x"})
+	if w.Code != http.StatusOK || !procesado {
+		t.Errorf("se obtuvo %d, procesado: %v", w.Code, procesado)
+	}
+}
@@ -0,0 +1,209 @@
+package apirest
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ResultadoDeLimite es el resultado de consumir una solicitud de una clave
+// en el almacén de límites.
+type ResultadoDeLimite struct {
+	Permitido         bool          // determina si la solicitud se encuentra permitida
+	Restantes         int           // cantidad de solicitudes restantes
+	Reinicio          time.Duration // tiempo hasta que se recuperan todas las solicitudes
+	ReintentarDespues time.Duration // tiempo hasta que se recupera una solicitud (si no se encuentra permitida)
+}
+
+// AlmacenDeLimites es la interface del almacén de los contadores de
+// solicitudes utilizado por InterceptorLimiteDeSolicitudes. Permite utilizar
+// un almacén compartido entre varias instancias de la aplicación.
+type AlmacenDeLimites interface {
+	// Consumir consume una solicitud de la clave recibida, con un límite de
+	// solicitudes por período.
+	Consumir(clave string, limite int, periodo time.Duration) (ResultadoDeLimite, error)
+}
+
+// ClaveDeLimiteFunc es el tipo (función) que obtiene la clave por la cual se
+// limitan las solicitudes (la IP del cliente, una clave de API, ...).
+type ClaveDeLimiteFunc func(r *http.Request) string
+
+// ClaveDeLimitePorIP limita las solicitudes por la dirección IP del cliente.
+func ClaveDeLimitePorIP() ClaveDeLimiteFunc {
+	return func(r *http.Request) string {
+		return "ip:" + obtenerIPRemota(r)
+	}
+}
+
+// ClaveDeLimitePorCampo limita las solicitudes por el valor de un campo de
+// cabecera (por ejemplo, "X-API-Key") junto con la dirección IP del cliente.
+// Si la solicitud no posee el campo, se limita sólo por la dirección IP.
+// El campo debe verificarse en un interceptor que se procese antes que el
+// límite de solicitudes: un cliente que cambia el valor de un campo no
+// verificado obtiene una cubeta nueva en cada solicitud.
+func ClaveDeLimitePorCampo(campoDeCabecera string) ClaveDeLimiteFunc {
+	return func(r *http.Request) string {
+		if valor := r.Header.Get(campoDeCabecera); valor != "" {
+			return "campo:" + valor + "|ip:" + obtenerIPRemota(r)
+		}
+		return "ip:" + obtenerIPRemota(r)
+	}
+}
+
+// ClaveDeLimitePorUsuario limita las solicitudes por el usuario autenticado
+// (ver ObtenerUsuario), por lo que debe procesarse después de un interceptor
+// de autenticación. Si la solicitud no fue autenticada, se limita por la
+// dirección IP del cliente.
+func ClaveDeLimitePorUsuario() ClaveDeLimiteFunc {
+	return func(r *http.Request) string {
+		if usuario := ObtenerUsuario(r); usuario != "" {
+			return "usuario:" + usuario
+		}
+		return "ip:" + obtenerIPRemota(r)
+	}
+}
+
+// OpcionesLimite establece el comportamiento de InterceptorLimiteDeSolicitudes.
+type OpcionesLimite struct {
+	Limite  int               // cantidad de solicitudes permitidas por período
+	Periodo time.Duration     // período en el que se recuperan todas las solicitudes
+	Clave   ClaveDeLimiteFunc // clave por la cual se limitan las solicitudes (por defecto, ClaveDeLimitePorIP)
+	Almacen AlmacenDeLimites  // almacén de los contadores (por defecto, CrearAlmacenDeLimitesEnMemoria)
+}
+
+// InterceptorLimiteDeSolicitudes devuelve un interceptor (middleware) que
+// limita la cantidad de solicitudes por clave (cubeta de fichas: se permiten
+// ráfagas de hasta Limite solicitudes y se recuperan de forma gradual durante
+// el Periodo). Las respuestas incluyen los campos de cabecera
+// "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset" y
+// "RateLimit-Policy"; al superar el límite se responde el error 429
+// (Demasiados requerimientos) con el campo de cabecera "Retry-After".
+// Si el almacén devuelve un error, la solicitud se permite.
+// Si el Limite o el Periodo no son mayores a cero, el interceptor es inválido:
+// el problema se informa como un conflicto de registro del enrutador
+// (ConflictoInterceptorInvalido, ver Validar).
+// Ejemplo:
+// 	r.Usar(apirest.InterceptorLimiteDeSolicitudes(apirest.OpcionesLimite{Limite: 100, Periodo: time.Minute}))
+func InterceptorLimiteDeSolicitudes(opciones OpcionesLimite) InterceptorFunc {
+	if opciones.Limite <= 0 || opciones.Periodo <= 0 {
+		return interceptorInvalido("el límite (%v) y el período (%v) de solicitudes deben ser mayores a cero", opciones.Limite, opciones.Periodo)
+	}
+	if opciones.Clave == nil {
+		opciones.Clave = ClaveDeLimitePorIP()
+	}
+	if opciones.Almacen == nil {
+		opciones.Almacen = CrearAlmacenDeLimitesEnMemoria()
+	}
+	politica := fmt.Sprintf("%d;w=%d", opciones.Limite, segundosHaciaArriba(opciones.Periodo))
+
+	return func(manejadorFunc ManejadorFunc) ManejadorFunc {
+		return func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+			resultado, err := opciones.Almacen.Consumir(opciones.Clave(r), opciones.Limite, opciones.Periodo)
+			if err != nil {
+				return manejadorFunc(w, r)
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(opciones.Limite))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(resultado.Restantes))
+			w.Header().Set("RateLimit-Reset", strconv.FormatInt(segundosHaciaArriba(resultado.Reinicio), 10))
+			w.Header().Set("RateLimit-Policy", politica)
+			if !resultado.Permitido {
+				return nil, ErrorNuevoDemasiadosRequerimientos("Se superó el límite de solicitudes permitidas").
+					AsignarCodigo("apirest.limiteDeSolicitudes").
+					AsignarReintentarDespues(resultado.ReintentarDespues)
+			}
+
+			return manejadorFunc(w, r)
+		}
+	}
+}
+
+// segundosHaciaArriba devuelve la duración en segundos, redondeando hacia
+// arriba.
+func segundosHaciaArriba(duracion time.Duration) int64 {
+	return int64((duracion + time.Second - 1) / time.Second)
+}
+
+// -----------------------------------------------------------------------------
+// Almacén de límites en memoria.
+
+// cubetaDeFichas almacena las fichas (solicitudes) disponibles de una clave.
+type cubetaDeFichas struct {
+	fichas        float64       // fichas disponibles
+	periodo       time.Duration // período en el que se recuperan todas las fichas
+	actualizacion time.Time     // fecha y hora de la última actualización
+}
+
+// almacenDeLimitesEnMemoria es el almacén de límites por defecto: mantiene una
+// cubeta de fichas por clave en la memoria de la aplicación.
+type almacenDeLimitesEnMemoria struct {
+	mutex         sync.Mutex
+	cubetas       map[string]*cubetaDeFichas
+	limpieza      time.Time     // fecha y hora de la última limpieza de cubetas llenas
+	periodoMinimo time.Duration // menor período recibido, que determina la frecuencia de la limpieza
+}
+
+// CrearAlmacenDeLimitesEnMemoria crea un almacén de límites en la memoria de
+// la aplicación. Las cubetas que se recuperaron por completo se eliminan de
+// forma periódica. El almacén puede compartirse entre interceptores con
+// distintos períodos: cada cubeta se elimina según su propio período.
+func CrearAlmacenDeLimitesEnMemoria() *almacenDeLimitesEnMemoria {
+	return &almacenDeLimitesEnMemoria{
+		cubetas:  make(map[string]*cubetaDeFichas),
+		limpieza: time.Now(),
+	}
+}
+
+// Consumir consume una ficha de la cubeta de la clave recibida.
+func (o *almacenDeLimitesEnMemoria) Consumir(clave string, limite int, periodo time.Duration) (ResultadoDeLimite, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	ahora := time.Now()
+	fichasPorSegundo := float64(limite) / periodo.Seconds()
+	if o.periodoMinimo == 0 || periodo < o.periodoMinimo {
+		o.periodoMinimo = periodo
+	}
+	o.limpiar(ahora)
+
+	cubeta, ok := o.cubetas[clave]
+	if !ok {
+		cubeta = &cubetaDeFichas{fichas: float64(limite), actualizacion: ahora}
+		o.cubetas[clave] = cubeta
+	}
+	cubeta.periodo = periodo
+
+	// recuperar las fichas desde la última actualización
+	cubeta.fichas = math.Min(float64(limite), cubeta.fichas+ahora.Sub(cubeta.actualizacion).Seconds()*fichasPorSegundo)
+	cubeta.actualizacion = ahora
+
+	var resultado ResultadoDeLimite
+	if cubeta.fichas >= 1 {
+		cubeta.fichas--
+		resultado.Permitido = true
+	} else {
+		resultado.ReintentarDespues = time.Duration((1 - cubeta.fichas) / fichasPorSegundo * float64(time.Second))
+	}
+	resultado.Restantes = int(cubeta.fichas)
+	resultado.Reinicio = time.Duration((float64(limite) - cubeta.fichas) / fichasPorSegundo * float64(time.Second))
+
+	return resultado, nil
+}
+
+// limpiar elimina, una vez por el menor período recibido, las cubetas que no
+// se actualizaron durante su propio período (se encuentran llenas).
+func (o *almacenDeLimitesEnMemoria) limpiar(ahora time.Time) {
+	if ahora.Sub(o.limpieza) < o.periodoMinimo {
+		return
+	}
+
+	for clave, cubeta := range o.cubetas {
+		if ahora.Sub(cubeta.actualizacion) >= cubeta.periodo {
+			delete(o.cubetas, clave)
+		}
+	}
+	o.limpieza = ahora
+}
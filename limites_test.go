@@ -0,0 +1,226 @@
+package apirest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestInterceptorLimiteDeSolicitudes(t *testing.T) {
+	var procesadas int
+	r := CrearEnrutador()
+	r.Usar(InterceptorLimiteDeSolicitudes(OpcionesLimite{Limite: 2, Periodo: time.Minute}))
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		procesadas++
+		return "ok", nil
+	})
+
+	pruebas := []struct {
+		estado     int
+		restantes  string
+		reinicio   string
+		retryAfter string
+	}{
+		{http.StatusOK, "1", "30", ""},
+		{http.StatusOK, "0", "60", ""},
+		{http.StatusTooManyRequests, "0", "60", "30"},
+	}
+	for i, p := range pruebas {
+		w := solicitar(r, "GET", "/personas", nil)
+		cabecera := w.Header()
+		if w.Code != p.estado || cabecera.Get("RateLimit-Limit") != "2" || cabecera.Get("RateLimit-Remaining") != p.restantes ||
+			cabecera.Get("RateLimit-Reset") != p.reinicio || cabecera.Get("RateLimit-Policy") != "2;w=60" ||
+			cabecera.Get("Retry-After") != p.retryAfter {
+			t.Errorf("%d: se obtuvo %d %v", i, w.Code, cabecera)
+		}
+		if p.estado == http.StatusTooManyRequests {
+			if c := decodificarCuerpoDeError(t, w); c.Error.Codigo != "apirest.limiteDeSolicitudes" {
+				t.Errorf("%d: se obtuvo el código %q", i, c.Error.Codigo)
+			}
+		}
+	}
+	if procesadas != 2 {
+		t.Errorf("se procesaron %d solicitudes", procesadas)
+	}
+}
+
+func TestInterceptorLimiteDeSolicitudesPorClave(t *testing.T) {
+	r := CrearEnrutador()
+	r.Usar(InterceptorLimiteDeSolicitudes(OpcionesLimite{Limite: 1, Periodo: time.Minute, Clave: ClaveDeLimitePorCampo("X-API-Key")}))
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, nil
+	})
+
+	for _, p := range []struct {
+		clave  string
+		estado int
+	}{
+		{"a", http.StatusNoContent},
+		{"b", http.StatusNoContent},
+		{"a", http.StatusTooManyRequests},
+		// sin el campo, se limita por la IP del cliente
+		{"", http.StatusNoContent},
+		{"", http.StatusTooManyRequests},
+	} {
+		if w := solicitar(r, "GET", "/personas", map[string]string{"X-API-Key": p.clave}); w.Code != p.estado {
+			t.Errorf("%q: se obtuvo %d, se esperaba %d", p.clave, w.Code, p.estado)
+		}
+	}
+
+	// el valor del campo se combina con la IP del cliente
+	solicitud := httptest.NewRequest("GET", "/personas", nil)
+	solicitud.Header.Set("X-API-Key", "a")
+	if clave := ClaveDeLimitePorCampo("X-API-Key")(solicitud); clave != "campo:a|ip:192.0.2.1" {
+		t.Errorf("se obtuvo la clave %q", clave)
+	}
+}
+
+func TestAlmacenDeLimitesEnMemoriaRecuperaFichas(t *testing.T) {
+	almacen := CrearAlmacenDeLimitesEnMemoria()
+	// 2 fichas cada 100ms: se recupera una ficha cada 50ms
+	periodo := 100 * time.Millisecond
+
+	for i := 0; i < 2; i++ {
+		if resultado, _ := almacen.Consumir("a", 2, periodo); !resultado.Permitido {
+			t.Fatalf("%d: no se permitió la solicitud", i)
+		}
+	}
+	resultado, err := almacen.Consumir("a", 2, periodo)
+	if err != nil || resultado.Permitido || resultado.ReintentarDespues <= 0 || resultado.ReintentarDespues > 50*time.Millisecond {
+		t.Fatalf("se obtuvo %+v %v", resultado, err)
+	}
+
+	time.Sleep(resultado.ReintentarDespues + 10*time.Millisecond)
+	if resultado, _ := almacen.Consumir("a", 2, periodo); !resultado.Permitido || resultado.Restantes != 0 {
+		t.Errorf("no se recuperó la ficha: %+v", resultado)
+	}
+
+	// las fichas no superan el límite
+	time.Sleep(2 * periodo)
+	if resultado, _ := almacen.Consumir("a", 2, periodo); !resultado.Permitido || resultado.Restantes != 1 {
+		t.Errorf("se obtuvo %+v", resultado)
+	}
+}
+
+func TestAlmacenDeLimitesEnMemoriaLimpiaCubetas(t *testing.T) {
+	almacen := CrearAlmacenDeLimitesEnMemoria()
+	periodo := 20 * time.Millisecond
+	almacen.Consumir("a", 1, periodo)
+	almacen.Consumir("b", 1, periodo)
+
+	time.Sleep(periodo)
+	almacen.Consumir("c", 1, periodo)
+	if _, ok := almacen.cubetas["a"]; ok || len(almacen.cubetas) != 1 {
+		t.Errorf("no se eliminaron las cubetas llenas: %v", almacen.cubetas)
+	}
+}
+
+func TestAlmacenDeLimitesEnMemoriaCompartido(t *testing.T) {
+	// un almacén compartido por interceptores con distintos períodos
+	almacen := CrearAlmacenDeLimitesEnMemoria()
+	corto := 20 * time.Millisecond
+	almacen.Consumir("hora", 1, time.Hour)
+	almacen.Consumir("corto", 1, corto)
+
+	// la limpieza del período corto no elimina la cubeta del período largo,
+	// que aún no recuperó sus fichas
+	time.Sleep(corto)
+	almacen.Consumir("otro", 1, corto)
+	if _, ok := almacen.cubetas["corto"]; ok {
+		t.Errorf("no se eliminó la cubeta llena del período corto")
+	}
+	if resultado, _ := almacen.Consumir("hora", 1, time.Hour); resultado.Permitido {
+		t.Errorf("se reinició la cubeta del período largo: %+v", resultado)
+	}
+}
+
+// almacenDeLimitesConError es un almacén que siempre devuelve un error.
+type almacenDeLimitesConError struct{}
+
+func (almacenDeLimitesConError) Consumir(clave string, limite int, periodo time.Duration) (ResultadoDeLimite, error) {
+	return ResultadoDeLimite{}, errors.New("sin conexión")
+}
+
+func TestInterceptorLimiteDeSolicitudesConErrorDelAlmacen(t *testing.T) {
+	r := CrearEnrutador()
+	r.Usar(InterceptorLimiteDeSolicitudes(OpcionesLimite{Limite: 1, Periodo: time.Minute, Almacen: almacenDeLimitesConError{}}))
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return "ok", nil
+	})
+
+	// la solicitud se permite, sin informar el límite
+	for i := 0; i < 3; i++ {
+		if w := solicitar(r, "GET", "/personas", nil); w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+			t.Errorf("%d: se obtuvo %d %v", i, w.Code, w.Header())
+		}
+	}
+}
+
+func TestInterceptorLimiteDeSolicitudesOpcionesInvalidas(t *testing.T) {
+	for _, opciones := range []OpcionesLimite{{Limite: 0, Periodo: time.Minute}, {Limite: 1}} {
+		var procesada bool
+		r := CrearEnrutador()
+		r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+			procesada = true
+			return nil, nil
+		}).Interceptores(InterceptorLimiteDeSolicitudes(opciones))
+
+		if err := r.Validar(); !ErrorEsRegistro(err, ConflictoInterceptorInvalido) {
+			t.Errorf("%+v: se esperaba un conflicto de interceptor inválido, se obtuvo %v", opciones, err)
+		}
+		// la función no se procesa sin el interceptor
+		if w := solicitar(r, "GET", "/personas", nil); w.Code != http.StatusInternalServerError || procesada {
+			t.Errorf("%+v: se obtuvo %d, procesada: %v", opciones, w.Code, procesada)
+		}
+	}
+}
+
+func TestInterceptorLimiteDeSolicitudesOpcionesInvalidasEnElEnrutador(t *testing.T) {
+	var marcas []string
+	invalido := InterceptorLimiteDeSolicitudes(OpcionesLimite{Limite: 1})
+	r := CrearEnrutador()
+	r.Usar(invalido)
+	r.Usar(interceptorMarcador(&marcas, "uno"))
+
+	// el problema se agrega una única vez, aunque el interceptor se encadene
+	// nuevamente al agregar otros interceptores
+	err := r.Validar()
+	if errores, ok := err.(erroresDeRegistro); !ok || len(errores) != 1 || errores[0].ObtenerConflicto() != ConflictoInterceptorInvalido {
+		t.Errorf("se obtuvo %v", err)
+	}
+
+	// en modo estricto, el problema se informa a través de un pánico
+	defer func() {
+		if errRegistro, ok := recover().(*errorDeRegistro); !ok || errRegistro.ObtenerConflicto() != ConflictoInterceptorInvalido {
+			t.Errorf("se esperaba un pánico con el conflicto, se obtuvo %v", errRegistro)
+		}
+	}()
+	CrearEnrutador().ModoEstricto().Usar(invalido)
+}
+
+func TestClaveDeLimitePorUsuario(t *testing.T) {
+	r := CrearEnrutador()
+	r.Usar(
+		InterceptorClaveDeAPI("X-API-Key", map[string]string{"clave-1": "facturacion", "clave-2": "facturacion", "clave-3": "ventas"}),
+		InterceptorLimiteDeSolicitudes(OpcionesLimite{Limite: 1, Periodo: time.Minute, Clave: ClaveDeLimitePorUsuario()}),
+	)
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, nil
+	})
+
+	// las claves de un mismo usuario comparten la cubeta
+	for _, p := range []struct {
+		clave  string
+		estado int
+	}{
+		{"clave-1", http.StatusNoContent},
+		{"clave-2", http.StatusTooManyRequests},
+		{"clave-3", http.StatusNoContent},
+	} {
+		if w := solicitar(r, "GET", "/personas", map[string]string{"X-API-Key": p.clave}); w.Code != p.estado {
+			t.Errorf("%q: se obtuvo %d, se esperaba %d", p.clave, w.Code, p.estado)
+		}
+	}
+}
@@ -4,11 +4,16 @@ package apirest
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"regexp"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 )
 
 // cors almacena los nombres de los campos de la cabecera CORS.
@@ -27,6 +32,22 @@ var cors = struct {
 	"Access-Control-Expose-Headers",
 }
 
+// claveDeContexto es el tipo de las claves con las que el paquete almacena
+// valores en el contexto de la solicitud. Al no ser exportado, las claves no
+// colisionan con las claves de otros paquetes.
+type claveDeContexto int
+
+// Claves de los valores almacenados en el contexto de la solicitud.
+const (
+	claveVariables     claveDeContexto = iota // variables del patrón de ruta
+	claveCORS                                 // campos de cabecera CORS de la respuesta
+	clavePanico                               // valor del pánico recuperado por el enrutador
+	claveIDDeSolicitud                        // identificador de la solicitud
+	claveAcceso                               // datos del acceso (patrón de ruta y variables)
+	claveIdentidad                            // identidad autenticada por los interceptores de autenticación
+	clavePreflightCORS                        // la solicitud es una verificación previa CORS que responde el enrutador
+)
+
 // ManejadorFunc es el tipo (función) que procesa el requirimiento del recurso.
 type ManejadorFunc func(w http.ResponseWriter, r *http.Request) (interface{}, error)
 
@@ -39,7 +60,9 @@ func (m ManejadorFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) (interf
 // 	ejemplos:
 //	"/personas"
 //	"/personas/{v}"
+//	"/personas/{v:int}"
 //	"/personas/{v}/datos_principales"
+//	"/archivos/{v...}"
 type patronDeRuta string
 
 // string convierte a string el tipo patrón de ruta.
@@ -50,8 +73,42 @@ func (pr patronDeRuta) string() string {
 // endpoint almacena un apuntador al detalle del patrón de ruta y la función
 // (ManejadorFunc) a procesar.
 type endpoint struct {
-	detalle *patronDeRutaDetalle // apuntador al detalle del patrón de ruta al cuál pertenece el endpoint
-	funcion ManejadorFunc        // función (ManejadorFunc) a procesar
+	detalle       *patronDeRutaDetalle // apuntador al detalle del patrón de ruta al cuál pertenece el endpoint
+	funcion       ManejadorFunc        // función (ManejadorFunc) a procesar, encadenada con sus interceptores
+	manejador     ManejadorFunc        // función (ManejadorFunc) original, sin interceptores
+	interceptores []InterceptorFunc    // interceptores (middlewares) propios del endpoint
+	grupo         *Grupo               // grupo al cuál pertenece el endpoint (es opcional)
+	enrutador     *enrutador           // enrutador en el cuál se registró el endpoint
+}
+
+// Interceptores agrega interceptores (middlewares) al endpoint. Se procesan
+// después de los interceptores del enrutador y de los grupos a los que
+// pertenece el endpoint.
+// Ejemplo:
+// 	r.POST("/personas", crearPersona).Interceptores(autenticar(), auditar())
+func (o *endpoint) Interceptores(funciones ...InterceptorFunc) *endpoint {
+	o.interceptores = append(o.interceptores, funciones...)
+	o.encadenar()
+
+	return o
+}
+
+// endpointNoRegistrado crea un endpoint que no forma parte del enrutador, para
+// que los métodos encadenados luego de un registro fallido no fallen.
+func (o *enrutador) endpointNoRegistrado(funcion ManejadorFunc) *endpoint {
+	return &endpoint{detalle: &patronDeRutaDetalle{}, funcion: funcion, manejador: funcion, enrutador: o}
+}
+
+// encadenar encadena la función original del endpoint con los interceptores
+// (middlewares) de los grupos a los que pertenece y con los propios.
+func (o *endpoint) encadenar() {
+	var funciones []InterceptorFunc
+	if o.grupo != nil {
+		funciones = o.grupo.obtenerInterceptores()
+	}
+	funciones = append(funciones, o.interceptores...)
+
+	o.funcion = o.enrutador.encadenarInterceptores(funciones, o.manejador)
 }
 
 // CORSCamposRequeridos solicita los campos CORS requeridos para poder procesar
@@ -96,10 +153,12 @@ func (o *endpoint) CORSCamposExpuestos(campos ...string) *endpoint {
 }
 
 // variableDePatronDeRuta almacena los valores de una parte variable
-// (la posición y el nombre) que contenga el patrón de ruta.
+// (la posición, el nombre y la restricción) que contenga el patrón de ruta.
 type variableDePatronDeRuta struct {
-	posicion int
-	nombre   string
+	posicion    int
+	nombre      string
+	restriccion *restriccion // restricción que debe cumplir el valor recibido (es opcional)
+	esComodin   bool         // determina que la variable captura el resto de la ruta ("{ruta...}")
 }
 
 // patronDeRutaDetalle almacena por cada patrón de ruta, todos los endpoint que
@@ -120,10 +179,49 @@ type patronDeRutaDetalle struct {
 		camposExpuestos []string
 	}
 
+	ruta      string                   // ruta con la que se registró el patrón de ruta ("/personas/{id:int}")
 	endpoints map[string]*endpoint     // cada patrón de ruta puede poseer un endpoint distinto por cada método HTTP
 	variables []variableDePatronDeRuta // almacena las variables (posición y nombre) de todas las partes variables que posee el patrón de ruta
 }
 
+// buscarEndpoint devuelve el endpoint del método recibido. Las solicitudes
+// HEAD se procesan con el endpoint GET, salvo que exista un endpoint HEAD.
+func (o *patronDeRutaDetalle) buscarEndpoint(metodo string) (*endpoint, bool) {
+	ep, ok := o.endpoints[metodo]
+	if !ok && metodo == "HEAD" {
+		ep, ok = o.endpoints["GET"]
+	}
+
+	return ep, ok
+}
+
+// metodosAllow devuelve el valor del campo de cabecera "Allow": los métodos
+// permitidos por el patrón de ruta, incluidos HEAD (si existe GET) y OPTIONS.
+func (o *patronDeRutaDetalle) metodosAllow() string {
+	var metodos = make([]string, 0, len(o.cors.metodosPermitidos)+2)
+	metodos = append(metodos, o.cors.metodosPermitidos...)
+	if _, ok := o.buscarEndpoint("HEAD"); ok && !contieneTexto(metodos, "HEAD") {
+		metodos = append(metodos, "HEAD")
+	}
+	if !contieneTexto(metodos, "OPTIONS") {
+		metodos = append(metodos, "OPTIONS")
+	}
+
+	return strings.Join(metodos, ", ")
+}
+
+// restriccionDeVariable devuelve la restricción de la variable que se
+// encuentra en la posición recibida del patrón de ruta.
+func (o *patronDeRutaDetalle) restriccionDeVariable(posicion int) *restriccion {
+	for _, variable := range o.variables {
+		if variable.posicion == posicion {
+			return variable.restriccion
+		}
+	}
+
+	return nil
+}
+
 // enrutador almacena los valores de los campos generales de CORS y todos los
 // patrones de ruta de la aplicación.
 type enrutador struct {
@@ -138,8 +236,13 @@ type enrutador struct {
 		// El explorador asegura esto. Para solicitudes sin credenciales, el
 		// servidor debe especificar "*" como un comodín, de este modo se
 		// permite a cualquier origen acceder al recurso.
+		// Se responde únicamente el origen recibido que coincida con la
+		// lista (ver corsOrigenPermitido).
 		origenes []string
 
+		// expresiones almacena expresiones regulares de orígenes permitidos.
+		expresiones []*regexp.Regexp
+
 		// credenciales almacena el valor por defecto del campo de la cabecera
 		// "Access-Control-Allow-Credentials" para todos los endpoints.
 		// Indica si la respuesta puede ser expuesta cuando el campo de la
@@ -158,11 +261,91 @@ type enrutador struct {
 
 	// mapa de patrones de rutas con su detalle
 	patronesDeRutas map[patronDeRuta]*patronDeRutaDetalle
+
+	// raiz es el nodo raíz del árbol de patrones de rutas, utilizado para
+	// buscar el patrón de ruta que coincide con la ruta recibida.
+	raiz *nodo
+
+	// interceptores almacena los interceptores (middlewares) que se procesan
+	// en todas las solicitudes, incluidas las que no poseen un endpoint.
+	interceptores []InterceptorFunc
+
+	// manejador es la función de despacho encadenada con los interceptores
+	// del enrutador.
+	manejador ManejadorFunc
+
+	// servidor almacena la configuración y el servidor HTTP en ejecución.
+	servidor servidor
+
+	// erroresDeRegistro almacena los problemas detectados al registrar los
+	// endpoints (ver Validar).
+	erroresDeRegistro []*errorDeRegistro
+
+	// esEstricto determina que los problemas de registro se informan
+	// inmediatamente a través de un pánico (ver ModoEstricto).
+	esEstricto bool
+
+	// manejadores almacena las funciones que reemplazan las respuestas
+	// generadas por el enrutador.
+	manejadores struct {
+		noEncontrado      ManejadorFunc // ruta inexistente (404)
+		metodoNoPermitido ManejadorFunc // método no implementado (405)
+		panico            ManejadorFunc // pánico al procesar la solicitud
+	}
+
+	// registradorDePanicos registra los pánicos recuperados por el enrutador.
+	registradorDePanicos RegistradorFunc
+
+	// idDeSolicitud almacena la configuración del identificador de solicitud
+	// (ver IdentificadorDeSolicitud).
+	idDeSolicitud struct {
+		esActivo bool   // determina si se obtiene o genera el identificador
+		campo    string // campo de cabecera del identificador
+	}
+
+	// metricas almacena las métricas de las solicitudes; es nil si no se
+	// exponen las métricas (ver ExponerMetricas).
+	metricas *metricas
 }
 
 // ServeHTTP envía la solicitud a la función cuyo patrón de ruta coincida
-// con la URL de la solicitud.
+// con la URL de la solicitud. Los valores devueltos por la función
+// (ManejadorFunc) son utilizados para construir la respuesta, salvo que la
+// función ya haya escrito la respuesta por sí misma.
 func (o *enrutador) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if o.idDeSolicitud.esActivo {
+		r = o.asignarIDDeSolicitud(w, r)
+	}
+
+	if o.cors.esActivo && esPreflightCORS(r) {
+		r = r.WithContext(context.WithValue(r.Context(), clavePreflightCORS, true))
+	}
+
+	var escritor = &escritorDeRespuesta{ResponseWriter: w, esHEAD: r.Method == "HEAD"}
+	defer escritor.finalizarHEAD()
+
+	// el patrón de ruta se obtiene al despachar la solicitud; las funciones
+	// registradas en el acceso se ejecutan una vez respondida la solicitud
+	// (incluidos los pánicos)
+	acceso := &datosDeAcceso{}
+	r = r.WithContext(context.WithValue(r.Context(), claveAcceso, acceso))
+	defer acceso.finalizar(escritor)
+	if o.metricas != nil {
+		defer o.metricas.iniciar(escritor, r, acceso)()
+	}
+	defer o.recuperarPanico(escritor, r)
+
+	valor, err := o.manejador(escritor, r)
+	if escritor.escrito {
+		return
+	}
+
+	responderResultado(escritor, r, valor, err)
+}
+
+// despachar busca el endpoint que corresponde a la solicitud recibida y
+// ejecuta su función (ManejadorFunc), devolviendo sus valores de retorno.
+func (o *enrutador) despachar(w http.ResponseWriter, r *http.Request) (interface{}, error) {
 	// verificar la existencia de la ruta recibida
 	rutaRecibida := r.RequestURI
 	pos := strings.Index(rutaRecibida, "?")
@@ -172,52 +355,85 @@ func (o *enrutador) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	detallePtr, variables, encontrado := o.buscarPatronDeRuta(rutaRecibida)
 	if !encontrado {
-		responderError(w, HTTPEstadoErrorNoEncontrado, "apirest.uriInexistente", "La URI solicitada es inexistente")
-		return
+		if o.manejadores.noEncontrado != nil {
+			return o.manejadores.noEncontrado(w, r)
+		}
+		return nil, ErrorNuevoNoEncontrado("La URI solicitada es inexistente").AsignarCodigo("apirest.uriInexistente")
+	}
+	if acceso, ok := r.Context().Value(claveAcceso).(*datosDeAcceso); ok {
+		acceso.patron, acceso.variables = detallePtr.ruta, variables
 	}
 
-	var cabecerasCORS = make(map[string]string)
 	metodoRecibido := r.Method
 	if metodoRecibido == "OPTIONS" {
-		if !o.cors.esActivo {
-			responderError(w, HTTPEstadoErrorMetodoNoImplementado, "apirest.metodoNoImplementado", "La aplicación no implementa el método OPTIONS (No se encuentra activa la opcion CORS)")
-			return
+		// solicitud de verificación previa CORS (preflight)
+		if o.cors.esActivo && esPreflightCORS(r) {
+			if err := o.verificarPreflightCORS(r, detallePtr); err != nil {
+				return nil, err
+			}
+			for campo, valores := range o.cabecerasCORSPreflight(r, detallePtr) {
+				w.Header()[campo] = valores
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+			return nil, nil
+		}
+
+		// solicitud OPTIONS real: si no existe un endpoint OPTIONS, informar
+		// los métodos permitidos
+		if _, ok := detallePtr.endpoints[metodoRecibido]; !ok {
+			w.Header().Set("Allow", detallePtr.metodosAllow())
+			w.WriteHeader(http.StatusNoContent)
+			return nil, nil
+		}
+	}
+
+	// escribir las cabeceras CORS de la respuesta
+	var cabecerasCORS = make(map[string]string)
+	if o.cors.esActivo {
+		for campo, valores := range o.cabecerasCORSRespuesta(r, detallePtr) {
+			w.Header()[campo] = valores
+			cabecerasCORS[campo] = strings.Join(valores, ", ")
 		}
-		w.Header().Set(cors.AccessControlAllowOrigin, strings.Join(o.cors.origenes, ", "))
-		w.Header().Set(cors.AccessControlAllowCredentials, strconv.FormatBool(o.cors.credenciales))
-		w.Header().Set(cors.AccessControlMaxAge, strconv.Itoa(o.cors.duracion))
-		w.Header().Set(cors.AccessControlAllowMethods, strings.Join(detallePtr.cors.metodosPermitidos, ", "))
-		w.Header().Set(cors.AccessControlAllowHeaders, strings.Join(detallePtr.cors.camposRequeridos, ", "))
-		w.Header().Set(cors.AccessControlExposeHeaders, strings.Join(detallePtr.cors.camposExpuestos, ", "))
-
-		w.WriteHeader(http.StatusNoContent)
-		return
 	}
 
 	// si no es options... verificar la existencia del método HTTP recibido
-	ep, ok := detallePtr.endpoints[metodoRecibido]
+	ep, ok := detallePtr.buscarEndpoint(metodoRecibido)
 	if !ok {
-		responderError(w, HTTPEstadoErrorMetodoNoImplementado, "apirest.metodoNoImplementado", fmt.Sprintf("La ruta solicitada no implementa el método %v", metodoRecibido))
-		return
+		w.Header().Set("Allow", detallePtr.metodosAllow())
+		if o.manejadores.metodoNoPermitido != nil {
+			return o.manejadores.metodoNoPermitido(w, r)
+		}
+		return nil, ErrorNuevoMetodoNoImplementado("La ruta solicitada no implementa el método %v", metodoRecibido).AsignarCodigo("apirest.metodoNoImplementado")
 	}
 
 	// subir al contexto las cabeceras CORS y las variables de los patrones de ruta
 	ctx := r.Context()
 
 	if o.cors.esActivo {
-		cabecerasCORS[cors.AccessControlAllowOrigin] = strings.Join(o.cors.origenes, ", ")
-		cabecerasCORS[cors.AccessControlAllowCredentials] = strconv.FormatBool(o.cors.credenciales)
-		cabecerasCORS[cors.AccessControlMaxAge] = strconv.Itoa(o.cors.duracion)
-		cabecerasCORS[cors.AccessControlAllowMethods] = strings.Join(detallePtr.cors.metodosPermitidos, ", ")
-		cabecerasCORS[cors.AccessControlAllowHeaders] = strings.Join(detallePtr.cors.camposRequeridos, ", ")
-		cabecerasCORS[cors.AccessControlExposeHeaders] = strings.Join(detallePtr.cors.camposExpuestos, ", ")
-		ctx = context.WithValue(ctx, "cors", cabecerasCORS)
+		ctx = context.WithValue(ctx, claveCORS, cabecerasCORS)
 	}
 	if len(variables) > 0 {
-		ctx = context.WithValue(ctx, "variables", variables)
+		ctx = context.WithValue(ctx, claveVariables, variables)
 	}
 
-	ep.funcion(w, r.WithContext(ctx))
+	return ep.funcion(w, r.WithContext(ctx))
+}
+
+// Usar agrega interceptores (middlewares) que se procesan en todas las
+// solicitudes que recibe el enrutador, incluidas las respuestas que genera el
+// propio enrutador (URI inexistente, método no implementado y OPTIONS).
+// Los interceptores se procesan antes de que el enrutador responda los valores
+// devueltos por la función (ManejadorFunc): reciben el valor y el error, pero
+// no el código de estado ni la cantidad de bytes de la respuesta, salvo que la
+// función haya escrito la respuesta por sí misma.
+// Ejemplo:
+// 	r.Usar(registrarAccesos(), recuperar())
+func (o *enrutador) Usar(funciones ...InterceptorFunc) *enrutador {
+	o.interceptores = append(o.interceptores, funciones...)
+	o.manejador = o.encadenarInterceptores(o.interceptores, o.despachar)
+
+	return o
 }
 
 // CORSActivar determina que todos los recursos de la aplicación utilizarán CORS.
@@ -227,7 +443,10 @@ func (o *enrutador) CORSActivar() *enrutador {
 }
 
 // CORSOrigenes cambia el valor de los orígenes permitidos (URIs que pueden
-// tener acceso a los endpoints).
+// tener acceso a los endpoints). Además de orígenes exactos, se permiten
+// patrones de subdominios: "https://*.example.com".
+// Al recibir una solicitud, se responde únicamente el origen recibido (si se
+// encuentra permitido), junto con el campo de cabecera "Vary: Origin".
 // el el campo de cabecera: "Access-Control-Allow-Origin"
 // tiene como valor por defecto: "*".
 func (o *enrutador) CORSOrigenes(origenes ...string) *enrutador {
@@ -236,7 +455,8 @@ func (o *enrutador) CORSOrigenes(origenes ...string) *enrutador {
 }
 
 // COSCredenciales cambia el valor del requerimiento de credenciales para
-// consumir los recursos.
+// consumir los recursos. Las credenciales no pueden combinarse con el origen
+// "*" (ver Validar).
 // es el campo de cabecera: "Access-Control-Allow-Credentials"
 // tiene como valor por defecto: false.
 func (o *enrutador) COSCredenciales(credenciales bool) *enrutador {
@@ -253,7 +473,16 @@ func (o *enrutador) CORSDuracion(duracion int) *enrutador {
 	return o
 }
 
+// Manejar gestiona las operaciones de cualquier método HTTP, incluidos los
+// métodos no estándar (PROPFIND, QUERY, ...). El método debe ser un token
+// válido de HTTP y se distingue entre mayúsculas y minúsculas.
+func (o *enrutador) Manejar(metodo, ruta string, funcion ManejadorFunc) *endpoint {
+	return o.nuevoEndpoint(metodo, ruta, funcion)
+}
+
 // GET gestiona las operaciones GET de HTTP (consulta de recursos).
+// Las solicitudes HEAD se procesan automáticamente con el endpoint GET,
+// descartando el cuerpo de la respuesta.
 func (o *enrutador) GET(ruta string, funcion ManejadorFunc) *endpoint {
 	return o.nuevoEndpoint("GET", ruta, funcion)
 }
@@ -279,33 +508,64 @@ func (o *enrutador) DELETE(ruta string, funcion ManejadorFunc) *endpoint {
 }
 
 // IniciarPorHTTP inicia el servidor escuchando por HTTP.
+// Si existen problemas de registro de endpoints, no se inicia el servidor y se
+// devuelven los problemas (ver Validar).
 func (o *enrutador) IniciarPorHTTP(puerto string) error {
+	if err := o.Validar(); err != nil {
+		return err
+	}
 	return o.iniciar("http", puerto, "", "")
 }
 
 // IniciarPorHTTPS inicia el servidor escuchando por HTTPS.
+// Si existen problemas de registro de endpoints, no se inicia el servidor y se
+// devuelven los problemas (ver Validar).
 func (o *enrutador) IniciarPorHTTPS(puerto, certificadoPublico, certificadoPrivado string) error {
+	if err := o.Validar(); err != nil {
+		return err
+	}
 	return o.iniciar("https", puerto, certificadoPublico, certificadoPrivado)
 }
 
+// nuevoEndpoint registra un endpoint en el enrutador. Si existe un problema de
+// registro, el problema se agrega al enrutador (ver Validar) y se devuelve un
+// endpoint que no forma parte del enrutador.
 func (o *enrutador) nuevoEndpoint(metodo string, ruta string, funcion ManejadorFunc) *endpoint {
+	// verificar que el método sea un token válido de HTTP
+	if !esMetodoValido(metodo) {
+		o.registrarError(ConflictoMetodoInvalido, metodo, ruta, nil, "el método %q no es un método HTTP válido", metodo)
+		return o.endpointNoRegistrado(funcion)
+	}
+
 	// convertir la ruta ingresada por el desarrollador a un patrón de ruta
 	pr, variables, err := o.rutaAPatronDeRuta(ruta)
 	if err != nil {
-		finalizar("La ruta ingresada: [%v] %v, posee un error al intentar generar un patrón de ruta: %v", metodo, ruta, err)
+		o.registrarError(ConflictoRutaInvalida, metodo, ruta, err, "posee un error al intentar generar un patrón de ruta: %v", err)
+		return o.endpointNoRegistrado(funcion)
+	}
+
+	// verificar que la parte comodín (si existe) sea la última parte de la ruta
+	var cantidadDePartes = len(dividirRuta(pr.string()))
+	for _, variable := range variables {
+		if variable.esComodin && variable.posicion != cantidadDePartes-1 {
+			o.registrarError(ConflictoComodinNoFinal, metodo, ruta, nil, "posee una parte comodín que no es la última parte de la ruta")
+			return o.endpointNoRegistrado(funcion)
+		}
 	}
 
 	detallePtr, ok := o.patronesDeRutas[pr]
 	if !ok {
 		// crear un nuevo detalle del patrón de ruta
 		var detallePtr = &patronDeRutaDetalle{
+			ruta:      ruta,
 			variables: variables,
 		}
 		detallePtr.cors.metodosPermitidos = []string{metodo}
 
-		var epPtr = &endpoint{detalle: detallePtr, funcion: funcion} // crear un nuevo endpoint
-		detallePtr.endpoints = map[string]*endpoint{metodo: epPtr}   // agregar el endpoint en el detalle del patrón de ruta
-		o.patronesDeRutas[pr] = detallePtr                           // agregar el patrón de ruta en el mapa de patrones de rutas
+		var epPtr = &endpoint{detalle: detallePtr, funcion: funcion, manejador: funcion, enrutador: o} // crear un nuevo endpoint
+		detallePtr.endpoints = map[string]*endpoint{metodo: epPtr}                                     // agregar el endpoint en el detalle del patrón de ruta
+		o.patronesDeRutas[pr] = detallePtr                                                             // agregar el patrón de ruta en el mapa de patrones de rutas
+		o.raiz.insertar(dividirRuta(pr.string()), detallePtr)                                          // agregar el patrón de ruta en el árbol de búsqueda
 
 		return epPtr
 	}
@@ -314,45 +574,60 @@ func (o *enrutador) nuevoEndpoint(metodo string, ruta string, funcion ManejadorF
 	// mismos nombres
 	for i := 0; i < len(detallePtr.variables); i++ {
 		if detallePtr.variables[i].nombre != variables[i].nombre {
-			finalizar("Existe un patrón de ruta: %v, que contiene endpoints con distintos nombres de variables", pr)
+			o.registrarError(ConflictoVariablesDistintas, metodo, ruta, nil, "existe el patrón de ruta %v con distintos nombres de variables", pr)
+			return o.endpointNoRegistrado(funcion)
 		}
 	}
 
 	// verificar que no se pueda ingresar otro endpoint con el mismo método
 	// para este patrón de ruta.
 	if _, ok := o.patronesDeRutas[pr].endpoints[metodo]; ok {
-		finalizar("La ruta ingresada: [%v] %v, ya posee un endpoint creado con el mismo método", metodo, ruta)
+		o.registrarError(ConflictoMetodoDuplicado, metodo, ruta, nil, "ya posee un endpoint creado con el mismo método")
+		return o.endpointNoRegistrado(funcion)
 	}
 
-	detallePtr.cors.metodosPermitidos = append(detallePtr.cors.metodosPermitidos, metodo) // agregar el método permitido al detalle del patrón de ruta
-	var epPtr = &endpoint{detalle: detallePtr, funcion: funcion}                          // crear un nuevo endpoint
-	detallePtr.endpoints[metodo] = epPtr                                                  // asignar el nuevo endpoint
+	detallePtr.cors.metodosPermitidos = append(detallePtr.cors.metodosPermitidos, metodo)          // agregar el método permitido al detalle del patrón de ruta
+	var epPtr = &endpoint{detalle: detallePtr, funcion: funcion, manejador: funcion, enrutador: o} // crear un nuevo endpoint
+	detallePtr.endpoints[metodo] = epPtr                                                           // asignar el nuevo endpoint
 
 	return epPtr
 }
 
+// esMetodoValido verifica que el método sea un token de HTTP (RFC 9110).
+func esMetodoValido(metodo string) bool {
+	if metodo == "" {
+		return false
+	}
+	for _, c := range metodo {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("!#$%&'*+-.^_`|~", c)) {
+			return false
+		}
+	}
+
+	return true
+}
+
 // rutaAPatronDeRuta convierte la ruta ingresada por el desarrollador de la
 // aplicación a un patrón de ruta.
+// La parte comodín ("{ruta...}") captura cero o más partes: "/archivos"
+// coincide con "/archivos/{ruta...}" con la variable "ruta" vacía.
 func (o *enrutador) rutaAPatronDeRuta(s string) (patronDeRuta, []variableDePatronDeRuta, error) {
 	if s == "" {
 		return "", nil, fmt.Errorf("la ruta recibida está vacía")
 	}
-
-	var partesRuta = strings.Split(s, "/")
-	if partesRuta[0] == "" {
-		partesRuta = partesRuta[1:]
-	}
-	if partesRuta[len(partesRuta)-1] == "" {
-		partesRuta = partesRuta[:len(partesRuta)-1]
+	if err := verificarBarrasEnVariables(s); err != nil {
+		return "", nil, err
 	}
 
+	var partesRuta = dividirRuta(s)
+
 	var partes []string
 	var variables []variableDePatronDeRuta
 
 	for pos, parte := range partesRuta {
-		var p = strings.ToLower(strings.Trim(parte, " "))
+		var p = strings.Trim(parte, " ")
 		if strings.Index(p, "{") == -1 {
-			partes = append(partes, p)
+			partes = append(partes, strings.ToLower(p))
 			continue
 		}
 		if len(p) == 2 {
@@ -361,9 +636,38 @@ func (o *enrutador) rutaAPatronDeRuta(s string) (patronDeRuta, []variableDePatro
 		if strings.Index(p, "}") == -1 {
 			return "", nil, fmt.Errorf("el nombre de variable no contiene la llave de cierre")
 		}
+		if p[0] != '{' || p[len(p)-1] != '}' {
+			return "", nil, fmt.Errorf("la parte variable %v debe comenzar con '{' y finalizar con '}'", p)
+		}
 
-		variables = append(variables, variableDePatronDeRuta{posicion: pos, nombre: p[1 : len(p)-1]})
-		partes = append(partes, "{v}")
+		// separar el nombre de la variable de su restricción: "{id:int}"
+		var variable = variableDePatronDeRuta{posicion: pos, nombre: strings.ToLower(p[1 : len(p)-1])}
+		var parteDePatron = "{v}"
+		if strings.HasSuffix(p, "...}") {
+			// la variable es un comodín: "{ruta...}"
+			variable.nombre = strings.ToLower(strings.Trim(p[1:len(p)-4], " "))
+			variable.esComodin = true
+			if variable.nombre == "" {
+				return "", nil, fmt.Errorf("la parte comodín %v debe contener un nombre de variable", p)
+			}
+			parteDePatron = "{v...}"
+		} else if i := strings.Index(p, ":"); i != -1 {
+			variable.nombre = strings.ToLower(strings.Trim(p[1:i], " "))
+			expresion := strings.Trim(p[i+1:len(p)-1], " ")
+			if variable.nombre == "" || expresion == "" {
+				return "", nil, fmt.Errorf("la parte variable %v debe contener un nombre y una restricción", p)
+			}
+
+			r, err := nuevaRestriccion(expresion)
+			if err != nil {
+				return "", nil, err
+			}
+			variable.restriccion = r
+			parteDePatron = "{v:" + expresion + "}"
+		}
+
+		variables = append(variables, variable)
+		partes = append(partes, parteDePatron)
 	}
 
 	return patronDeRuta("/" + strings.Join(partes, "/")), variables, nil
@@ -371,66 +675,153 @@ func (o *enrutador) rutaAPatronDeRuta(s string) (patronDeRuta, []variableDePatro
 
 // buscarPatronDeRuta busca que exista el patrón de ruta, según la ruta recibida.
 func (o *enrutador) buscarPatronDeRuta(rutaRecibida string) (*patronDeRutaDetalle, map[string]string, bool) {
-	var partesRutaRecibida = strings.Split(rutaRecibida, "/")
-	if partesRutaRecibida[0] == "" {
-		partesRutaRecibida = partesRutaRecibida[1:]
-	}
-	if partesRutaRecibida[len(partesRutaRecibida)-1] == "" {
-		partesRutaRecibida = partesRutaRecibida[:len(partesRutaRecibida)-1]
-	}
+	var partesRutaRecibida = dividirRuta(rutaRecibida)
 
-	for pr, detallePtr := range o.patronesDeRutas {
-		partesPatronDeRuta := strings.Split(pr.string(), "/")[1:]
-
-		if len(partesRutaRecibida) != len(partesPatronDeRuta) {
-			continue
-		}
+	detallePtr := o.raiz.buscar(partesRutaRecibida)
+	if detallePtr == nil {
+		return nil, nil, false
+	}
 
-		// Verificar cada parte de la ruta recibida con la parte del patrón de ruta actual
-		encontrado := true
-		for i, partePatronActual := range partesPatronDeRuta {
-			switch {
-			case partePatronActual == partesRutaRecibida[i]:
-				continue
-			case partePatronActual == "{v}" && partePatronActual != partesRutaRecibida[i]:
-				continue
-			default:
-				encontrado = false
-				break
-			}
-		}
-		if encontrado {
-			var variables = make(map[string]string, len(detallePtr.variables))
-			// si se ha encontrado el patrón de ruta, crear el mapa de variables
-			for _, variable := range detallePtr.variables {
-				variables[variable.nombre] = partesRutaRecibida[variable.posicion]
+	// si se ha encontrado el patrón de ruta, crear el mapa de variables.
+	// la variable comodín captura el resto de la ruta (incluidas las barras).
+	var variables = make(map[string]string, len(detallePtr.variables))
+	for _, variable := range detallePtr.variables {
+		if variable.esComodin {
+			if variable.posicion < len(partesRutaRecibida) {
+				variables[variable.nombre] = strings.Join(partesRutaRecibida[variable.posicion:], "/")
+			} else {
+				variables[variable.nombre] = ""
 			}
-
-			return detallePtr, variables, true
+			continue
 		}
+		variables[variable.nombre] = partesRutaRecibida[variable.posicion]
 	}
 
-	return nil, nil, false
+	return detallePtr, variables, true
 }
 
 // iniciar inicia el servidor escuchando por el protocolo y puerto establecido.
+// Si el servidor se detiene ordenadamente (ver Detener), espera que finalice
+// la detención y no devuelve error.
 func (o *enrutador) iniciar(protocolo, puerto, certificadoPublico, certificadoPrivado string) error {
 	if puerto != "" && string(puerto[0]) != ":" {
 		puerto = ":" + puerto
 	}
 
-	if strings.ToUpper(strings.Trim(protocolo, " ")) == "HTTP" {
-		return http.ListenAndServe(puerto, o)
+	srv, detenido := o.crearServidorHTTP(puerto)
+	escuchar := func() error {
+		if strings.ToUpper(strings.Trim(protocolo, " ")) == "HTTP" {
+			return srv.ListenAndServe()
+		}
+		return srv.ListenAndServeTLS(certificadoPublico, certificadoPrivado)
+	}
+
+	if !o.servidor.opciones.DetenerConSenales {
+		if err := escuchar(); err != http.ErrServerClosed {
+			return err
+		}
+		<-detenido
+		return nil
 	}
 
-	return http.ListenAndServeTLS(puerto, certificadoPublico, certificadoPrivado, o)
+	// escuchar las señales de detención (SIGINT y SIGTERM)
+	var senales = make(chan os.Signal, 1)
+	signal.Notify(senales, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(senales)
+
+	var errores = make(chan error, 1)
+	go func() { errores <- escuchar() }()
+
+	select {
+	case err := <-errores:
+		if err != http.ErrServerClosed {
+			return err
+		}
+		<-detenido
+		return nil
+	case <-senales:
+	}
+
+	var tiempoDeDetencion = o.servidor.opciones.TiempoDeDetencion
+	if tiempoDeDetencion <= 0 {
+		tiempoDeDetencion = 30 * time.Second
+	}
+	ctx, cancelar := context.WithTimeout(context.Background(), tiempoDeDetencion)
+	defer cancelar()
+
+	return o.Detener(ctx)
 }
 
 // -----------------------------------------------------------------------------
 
+// cuerpoDeError es el sobre estándar con el que se responden los errores:
+// 	{"error": {"codigo": "...", "mensaje": "..."}}
+// Los errores de validación incluyen además la lista de errores de campos:
+// 	{"error": {"codigo": "apirest.validacion", "mensaje": "...",
+// 		"campos": [{"campo": "/email", "codigo": "formato", "mensaje": "..."}]}}
+type cuerpoDeError struct {
+	Error struct {
+		Codigo             string         `json:"codigo"`
+		Mensaje            string         `json:"mensaje"`
+		ValoresAdicionales []string       `json:"valoresAdicionales,omitempty"`
+		UUID               string         `json:"uuid,omitempty"`
+		Campos             []errorDeCampo `json:"campos,omitempty"`
+	} `json:"error"`
+}
+
 func responderError(w http.ResponseWriter, estadoHTTP HTTPEstado, codigo, mensaje string) {
-	var cuerpo = fmt.Sprintf("{\"error\": {\"codigo\": \"%v\", \"mensaje\": \"%v\"}}", codigo, mensaje)
-	HTTPResponder(w, estadoHTTP, HTTPContenidoApplicationJSON, nil, cuerpo)
+	var c cuerpoDeError
+	c.Error.Codigo, c.Error.Mensaje = codigo, mensaje
+	responderCuerpoDeError(w, estadoHTTP, c)
+}
+
+// responderErrorAPIREST responde el error recibido dentro del sobre estándar.
+// El código de estado HTTP se obtiene del primer error de tipo errorAPIREST
+// de la cadena de errores; si no existe, se responde como error interno del
+// servidor (500) sin exponer el mensaje del error original.
+// El identificador de la solicitud (ver IdentificadorDeSolicitud) se responde
+// como UUID cuando el error no posee uno; el error no se modifica, ya que
+// puede ser compartido por solicitudes concurrentes.
+func responderErrorAPIREST(w http.ResponseWriter, err error, idDeSolicitud string) {
+	var c cuerpoDeError
+	errAPIREST, ok := ErrorEsAPIREST(err)
+	if !ok {
+		c.Error.Codigo, c.Error.Mensaje = "apirest.errorInternoDeServidor", "Error interno del servidor"
+		c.Error.UUID = idDeSolicitud
+		responderCuerpoDeError(w, HTTPEstadoErrorInternoDeServidor, c)
+		return
+	}
+
+	c.Error.Codigo = errAPIREST.codigo
+	c.Error.Mensaje = errAPIREST.mensaje
+	c.Error.ValoresAdicionales = errAPIREST.valoresAdicionales
+	c.Error.UUID = errAPIREST.uuid
+	if c.Error.UUID == "" {
+		c.Error.UUID = idDeSolicitud
+	}
+	c.Error.Campos = errAPIREST.erroresDeCampos
+	cabeceraReintentarDespues(w, errAPIREST)
+	responderCuerpoDeError(w, errAPIREST.estadoHTTP, c)
+}
+
+// cabeceraReintentarDespues escribe el campo de cabecera "Retry-After" (en
+// segundos, redondeando hacia arriba) cuando el error posee un tiempo de
+// reintento asignado.
+func cabeceraReintentarDespues(w http.ResponseWriter, errAPIREST *errorAPIREST) {
+	if errAPIREST.reintentarDespues <= 0 {
+		return
+	}
+
+	w.Header().Set("Retry-After", strconv.FormatInt(segundosHaciaArriba(errAPIREST.reintentarDespues), 10))
+}
+
+func responderCuerpoDeError(w http.ResponseWriter, estadoHTTP HTTPEstado, c cuerpoDeError) {
+	cuerpo, err := json.Marshal(c)
+	if err != nil {
+		cuerpo = []byte(`{"error": {"codigo": "apirest.errorInternoDeServidor", "mensaje": "Error interno del servidor"}}`)
+		estadoHTTP = HTTPEstadoErrorInternoDeServidor
+	}
+	HTTPResponder(w, estadoHTTP, HTTPContenidoApplicationJSON, nil, string(cuerpo))
 }
 
 func finalizar(formato string, args ...interface{}) {
@@ -447,7 +838,9 @@ func Finalizar(formato string, args ...interface{}) {
 func CrearEnrutador() *enrutador {
 	var r = &enrutador{
 		patronesDeRutas: make(map[patronDeRuta]*patronDeRutaDetalle),
+		raiz:            &nodo{},
 	}
+	r.manejador = r.despachar
 
 	r.cors.origenes, r.cors.credenciales, r.cors.duracion = []string{"*"}, false, -1
 	return r
@@ -455,8 +848,10 @@ func CrearEnrutador() *enrutador {
 
 // ObtenerVariablesDeRuta retorna un mapa con los nombres de variables del patrón
 // de ruta junto con los valores recibos de la solicitud del cliente.
+// La variable comodín ("{ruta...}") contiene el resto de la ruta recibida,
+// incluidas las barras: "documentos/2021/informe.pdf".
 func ObtenerVariablesDeRuta(r *http.Request) map[string]string {
-	m, ok := r.Context().Value("variables").(map[string]string)
+	m, ok := r.Context().Value(claveVariables).(map[string]string)
 	if !ok {
 		return map[string]string{}
 	}
@@ -465,8 +860,10 @@ func ObtenerVariablesDeRuta(r *http.Request) map[string]string {
 }
 
 // ObtenerCORS retorna un mapa con los campos de la cabecera CORS.
+// El enrutador escribe estos campos en la respuesta; no es necesario que el
+// manejador los copie.
 func ObtenerCORS(r *http.Request) map[string]string {
-	m, ok := r.Context().Value("cors").(map[string]string)
+	m, ok := r.Context().Value(claveCORS).(map[string]string)
 	if !ok {
 		return map[string]string{}
 	}
@@ -0,0 +1,242 @@
+package apirest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// solicitar procesa una solicitud con el manejador recibido y devuelve la
+// respuesta grabada.
+func solicitar(h http.Handler, metodo, ruta string, cabecera map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(metodo, ruta, nil)
+	for campo, valor := range cabecera {
+		r.Header.Set(campo, valor)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	return w
+}
+
+// decodificarCuerpoDeError decodifica el sobre estándar de error de la
+// respuesta.
+func decodificarCuerpoDeError(t *testing.T, w *httptest.ResponseRecorder) cuerpoDeError {
+	t.Helper()
+
+	var c cuerpoDeError
+	if err := json.Unmarshal(w.Body.Bytes(), &c); err != nil {
+		t.Fatalf("el cuerpo %q no es un sobre de error: %v", w.Body.String(), err)
+	}
+
+	return c
+}
+
+func TestResponderValorDelManejador(t *testing.T) {
+	r := CrearEnrutador()
+	r.GET("/personas/{id}", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return map[string]string{"id": ObtenerVariablesDeRuta(r)["id"]}, nil
+	})
+	r.POST("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return map[string]int{"id": 7}, nil
+	})
+	r.DELETE("/personas/{id}", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, nil
+	})
+
+	pruebas := []struct {
+		metodo, ruta string
+		estado       int
+		cuerpo       string
+	}{
+		{"GET", "/personas/5", http.StatusOK, `{"id":"5"}`},
+		{"POST", "/personas", http.StatusCreated, `{"id":7}`},
+		{"DELETE", "/personas/5", http.StatusNoContent, ""},
+	}
+	for _, p := range pruebas {
+		w := solicitar(r, p.metodo, p.ruta, nil)
+		if w.Code != p.estado || w.Body.String() != p.cuerpo {
+			t.Errorf("%s %s: se obtuvo %d %q, se esperaba %d %q", p.metodo, p.ruta, w.Code, w.Body.String(), p.estado, p.cuerpo)
+		}
+	}
+}
+
+func TestRespuestaEscritaPorElManejador(t *testing.T) {
+	r := CrearEnrutador()
+	r.GET("/archivo", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("contenido"))
+
+		// los valores devueltos se ignoran: la respuesta ya fue escrita
+		return map[string]string{"ignorado": "si"}, ErrorNuevoInternoDeServidor("ignorado")
+	})
+
+	w := solicitar(r, "GET", "/archivo", nil)
+	if w.Code != http.StatusAccepted || w.Body.String() != "contenido" || w.Header().Get("Content-Type") != "text/plain" {
+		t.Errorf("se obtuvo %d %q %q", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+}
+
+func TestResponderErrorDelManejador(t *testing.T) {
+	pruebas := []struct {
+		nombre  string
+		err     error
+		estado  int
+		codigo  string
+		mensaje string
+	}{
+		{"errorAPIREST", ErrorNuevoNoEncontrado("No existe la persona").AsignarCodigo("personas.inexistente"),
+			http.StatusNotFound, "personas.inexistente", "No existe la persona"},
+		{"errorAPIREST envuelto", fmt.Errorf("crear persona: %w", ErrorNuevoConflicto("Ya existe la persona")),
+			http.StatusConflict, "", "Ya existe la persona"},
+		{"error sin tipo", errors.New("conexión rechazada"),
+			http.StatusInternalServerError, "apirest.errorInternoDeServidor", "Error interno del servidor"},
+	}
+	for _, p := range pruebas {
+		t.Run(p.nombre, func(t *testing.T) {
+			r := CrearEnrutador()
+			r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+				return nil, p.err
+			})
+
+			w := solicitar(r, "GET", "/personas", nil)
+			c := decodificarCuerpoDeError(t, w)
+			if w.Code != p.estado || c.Error.Codigo != p.codigo || c.Error.Mensaje != p.mensaje {
+				t.Errorf("se obtuvo %d %+v, se esperaba %d %q %q", w.Code, c.Error, p.estado, p.codigo, p.mensaje)
+			}
+			if tipo := w.Header().Get("Content-Type"); tipo != HTTPContenidoApplicationJSON.obtenerTexto() {
+				t.Errorf("tipo de contenido %q", tipo)
+			}
+		})
+	}
+}
+
+func TestParteComodin(t *testing.T) {
+	var variables map[string]string
+	r := CrearEnrutador()
+	r.GET("/archivos/{ruta...}", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		variables = ObtenerVariablesDeRuta(r)
+		return nil, nil
+	})
+
+	pruebas := map[string]string{
+		"/archivos/informe.pdf":             "informe.pdf",
+		"/archivos/2021/10/informe.pdf":     "2021/10/informe.pdf",
+		"/archivos/":                        "",
+		"/archivos":                         "",
+		"/archivos/con%20espacio/a%2Fb.txt": "con%20espacio/a%2Fb.txt",
+	}
+	for ruta, esperado := range pruebas {
+		variables = nil
+		if w := solicitar(r, "GET", ruta, nil); w.Code != http.StatusNoContent || variables["ruta"] != esperado {
+			t.Errorf("%s: se obtuvo %d %q, se esperaba %q", ruta, w.Code, variables["ruta"], esperado)
+		}
+	}
+}
+
+func TestParteComodinAlRegistrar(t *testing.T) {
+	pruebas := []struct {
+		ruta      string
+		conflicto ConflictoDeRegistro
+	}{
+		{"/archivos/{ruta...}/detalle", ConflictoComodinNoFinal},
+		{"/{ruta...}/{id}", ConflictoComodinNoFinal},
+		{"/archivos/{...}", ConflictoRutaInvalida},
+	}
+	for _, p := range pruebas {
+		r := CrearEnrutador()
+		r.GET(p.ruta, nil)
+		if err := r.Validar(); !ErrorEsRegistro(err, p.conflicto) {
+			t.Errorf("%s: se esperaba el conflicto %v, se obtuvo %v", p.ruta, p.conflicto, err)
+		}
+	}
+}
+
+func TestHEADDesdeGET(t *testing.T) {
+	r := CrearEnrutador()
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return map[string]string{"nombre": "Ana"}, nil
+	})
+	r.GET("/archivo", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("hola "))
+		w.Write([]byte("mundo"))
+		return nil, nil
+	})
+
+	for _, ruta := range []string{"/personas", "/archivo"} {
+		get := solicitar(r, "GET", ruta, nil)
+		head := solicitar(r, "HEAD", ruta, nil)
+		if head.Code != get.Code || head.Body.Len() != 0 ||
+			head.Header().Get("Content-Length") != fmt.Sprint(get.Body.Len()) ||
+			head.Header().Get("Content-Type") != get.Header().Get("Content-Type") {
+			t.Errorf("%s: HEAD %d %v %q, GET %d %v %q", ruta, head.Code, head.Header(), head.Body.String(), get.Code, get.Header(), get.Body.String())
+		}
+	}
+}
+
+func TestHEADConEndpointPropio(t *testing.T) {
+	r := CrearEnrutador()
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return "get", nil
+	})
+	r.Manejar("HEAD", "/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		w.Header().Set("X-Endpoint", "head")
+		return nil, nil
+	})
+
+	w := solicitar(r, "HEAD", "/personas", nil)
+	if w.Code != http.StatusNoContent || w.Header().Get("X-Endpoint") != "head" {
+		t.Errorf("se obtuvo %d %v", w.Code, w.Header())
+	}
+}
+
+func TestMetodoNoPermitido(t *testing.T) {
+	r := CrearEnrutador()
+	manejador := func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, nil
+	}
+	r.GET("/personas", manejador)
+	r.POST("/personas", manejador)
+	r.DELETE("/personas/{id}", manejador)
+
+	pruebas := []struct {
+		metodo, ruta, allow string
+	}{
+		{"PUT", "/personas", "GET, POST, HEAD, OPTIONS"},
+		{"HEAD", "/personas/5", "DELETE, OPTIONS"},
+		{"PROPFIND", "/personas/5", "DELETE, OPTIONS"},
+	}
+	for _, p := range pruebas {
+		w := solicitar(r, p.metodo, p.ruta, nil)
+		if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != p.allow {
+			t.Errorf("%s %s: se obtuvo %d %q, se esperaba 405 %q", p.metodo, p.ruta, w.Code, w.Header().Get("Allow"), p.allow)
+		}
+		if p.metodo == "HEAD" {
+			continue
+		}
+		if c := decodificarCuerpoDeError(t, w); c.Error.Codigo != "apirest.metodoNoImplementado" {
+			t.Errorf("%s %s: se obtuvo el código %q", p.metodo, p.ruta, c.Error.Codigo)
+		}
+	}
+}
+
+func TestManejarMetodosNoEstandar(t *testing.T) {
+	r := CrearEnrutador()
+	r.Manejar("PROPFIND", "/documentos/{ruta...}", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return ObtenerVariablesDeRuta(r)["ruta"], nil
+	})
+
+	if w := solicitar(r, "PROPFIND", "/documentos/a/b", nil); w.Code != http.StatusOK || w.Body.String() != `"a/b"` {
+		t.Errorf("se obtuvo %d %q", w.Code, w.Body.String())
+	}
+
+	// los métodos distinguen mayúsculas y minúsculas
+	if w := solicitar(r, "propfind", "/documentos/a/b", nil); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("se obtuvo %d", w.Code)
+	}
+}
@@ -0,0 +1,152 @@
+package apirest
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// ManejadorNoEncontrado establece la función que procesa las solicitudes cuya
+// ruta no coincide con ningún patrón de ruta (404). Por defecto se responde el
+// error "apirest.uriInexistente".
+func (o *enrutador) ManejadorNoEncontrado(funcion ManejadorFunc) *enrutador {
+	o.manejadores.noEncontrado = funcion
+	return o
+}
+
+// ManejadorMetodoNoPermitido establece la función que procesa las solicitudes
+// cuya ruta existe, pero no implementa el método recibido (405). Al invocar la
+// función, la respuesta ya posee el campo de cabecera "Allow" con los métodos
+// permitidos. Por defecto se responde el error "apirest.metodoNoImplementado".
+func (o *enrutador) ManejadorMetodoNoPermitido(funcion ManejadorFunc) *enrutador {
+	o.manejadores.metodoNoPermitido = funcion
+	return o
+}
+
+// ManejadorPanico establece la función que procesa las solicitudes en las que
+// se produjo un pánico. El valor del pánico se obtiene con ObtenerPanico.
+// Si la respuesta ya fue escrita antes del pánico, la función no se invoca.
+// Por defecto se responde el error "apirest.panico" (500) con su UUID; también
+// se responde este error si la función produce otro pánico.
+func (o *enrutador) ManejadorPanico(funcion ManejadorFunc) *enrutador {
+	o.manejadores.panico = funcion
+	return o
+}
+
+// RegistradorFunc es el tipo (función) que registra los errores producidos al
+// procesar una solicitud. Los rastros del error se obtienen con
+// ErrorObtenerRastros.
+type RegistradorFunc func(r *http.Request, err error)
+
+// RegistradorDePanicos establece la función que registra los pánicos
+// recuperados por el enrutador. Por defecto se registran a través del paquete
+// log (salida de errores estándar).
+func (o *enrutador) RegistradorDePanicos(funcion RegistradorFunc) *enrutador {
+	o.registradorDePanicos = funcion
+	return o
+}
+
+// registrarPanico es el registrador de pánicos por defecto.
+func registrarPanico(r *http.Request, err error) {
+	errAPIREST, _ := ErrorEsAPIREST(err)
+	log.Printf("apirest: pánico [%v] %v %v: %v\n%v", errAPIREST.ObtenerUUID(), r.Method, r.URL.Path, errAPIREST.ObtenerMensajeTecnico(), errAPIREST.ObtenerRastro().Observaciones)
+}
+
+// recuperarPanico recupera un pánico producido al procesar la solicitud: crea
+// un error interno del servidor (500) con su UUID (el identificador de la
+// solicitud, si se encuentra activo IdentificadorDeSolicitud), registra el
+// error junto con la pila de ejecución y responde el error (o la respuesta de
+// la función establecida en ManejadorPanico).
+// Los pánicos http.ErrAbortHandler se propagan para que el servidor aborte la
+// respuesta.
+func (o *enrutador) recuperarPanico(w *escritorDeRespuesta, r *http.Request) {
+	valorPanico := recover()
+	if valorPanico == nil {
+		return
+	}
+	if valorPanico == http.ErrAbortHandler {
+		panic(valorPanico)
+	}
+
+	errPanico := ErrorNuevoInternoDeServidor("Error interno del servidor").
+		AsignarCodigo("apirest.panico").
+		AsignarMensajeTecnico("pánico: %v", valorPanico).
+		AsignarObservacionAlRastro("%s", debug.Stack()).
+		AsignarUUIDDeSolicitud(r)
+	errPanico.asignarRastroDePanico()
+	if e, ok := valorPanico.(error); ok {
+		errPanico.errAnterior = e
+	}
+
+	var registrar = o.registradorDePanicos
+	if registrar == nil {
+		registrar = registrarPanico
+	}
+	registrar(r, errPanico)
+
+	if w.escrito {
+		return
+	}
+	if o.manejadores.panico == nil {
+		responderResultado(w, r, nil, errPanico)
+		return
+	}
+
+	// si la función establecida en ManejadorPanico produce otro pánico, se
+	// registra y se responde el error del pánico original
+	defer func() {
+		valorPanicoDelManejador := recover()
+		if valorPanicoDelManejador == nil {
+			return
+		}
+		if valorPanicoDelManejador == http.ErrAbortHandler {
+			panic(valorPanicoDelManejador)
+		}
+
+		registrar(r, ErrorNuevoInternoDeServidor("Error interno del servidor").
+			AsignarCodigo("apirest.panico").
+			AsignarMensajeTecnico("pánico en ManejadorPanico: %v", valorPanicoDelManejador).
+			AsignarUUIDDeSolicitud(r))
+		if !w.escrito {
+			responderResultado(w, r, nil, errPanico)
+		}
+	}()
+
+	r = r.WithContext(context.WithValue(r.Context(), clavePanico, valorPanico))
+	valor, err := o.manejadores.panico(w, r)
+	if !w.escrito {
+		responderResultado(w, r, valor, err)
+	}
+}
+
+// asignarRastroDePanico establece como ubicación del error la función donde
+// se produjo el pánico (la primera función, fuera del paquete runtime, luego
+// de runtime.gopanic).
+func (o *errorAPIREST) asignarRastroDePanico() {
+	var pcs [32]uintptr
+	marcos := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs[:])])
+
+	var esSiguiente bool
+	for {
+		marco, hayMas := marcos.Next()
+		if esSiguiente && !strings.HasPrefix(marco.Function, "runtime.") {
+			o.rastro.Archivo = strings.TrimSuffix(marco.File[strings.LastIndex(marco.File, "/")+1:], ".go")
+			o.rastro.Funcion = marco.Function
+			o.rastro.NroLinea = marco.Line
+			return
+		}
+		esSiguiente = esSiguiente || marco.Function == "runtime.gopanic"
+		if !hayMas {
+			return
+		}
+	}
+}
+
+// ObtenerPanico devuelve el valor del pánico recuperado por el enrutador.
+// Sólo posee valor dentro de la función establecida en ManejadorPanico.
+func ObtenerPanico(r *http.Request) interface{} {
+	return r.Context().Value(clavePanico)
+}
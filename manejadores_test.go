@@ -0,0 +1,167 @@
+package apirest
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestManejadoresDelEnrutador(t *testing.T) {
+	r := CrearEnrutador()
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, nil
+	})
+	r.ManejadorNoEncontrado(func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, ErrorNuevoNoEncontrado("Not found: %v", r.URL.Path).AsignarCodigo("app.notFound")
+	})
+	r.ManejadorMetodoNoPermitido(func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		// la respuesta ya posee el campo de cabecera "Allow"
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		w.Write([]byte("permitidos: " + w.Header().Get("Allow")))
+		return nil, nil
+	})
+
+	w := solicitar(r, "GET", "/inexistente", nil)
+	if c := decodificarCuerpoDeError(t, w); w.Code != http.StatusNotFound || c.Error.Codigo != "app.notFound" || c.Error.Mensaje != "Not found: /inexistente" {
+		t.Errorf("404: se obtuvo %d %+v", w.Code, c.Error)
+	}
+
+	w = solicitar(r, "DELETE", "/personas", nil)
+	if w.Code != http.StatusMethodNotAllowed || w.Body.String() != "permitidos: GET, HEAD, OPTIONS" {
+		t.Errorf("405: se obtuvo %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestManejadorNoEncontradoConValor(t *testing.T) {
+	// por ejemplo, la página de una aplicación de una sola página (SPA)
+	r := CrearEnrutador()
+	r.ManejadorNoEncontrado(func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, HTTPResponder(w, HTTPEstadoOk, HTTPContenidoTextHTML, nil, "<html></html>")
+	})
+
+	w := solicitar(r, "GET", "/app/pagina", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "<html></html>" {
+		t.Errorf("se obtuvo %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestManejadorPanico(t *testing.T) {
+	var valorPanico interface{}
+	r := CrearEnrutador().RegistradorDePanicos(func(r *http.Request, err error) {})
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		panic("sin conexión")
+	})
+	r.ManejadorPanico(func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		valorPanico = ObtenerPanico(r)
+		return nil, ErrorNuevoServicioNoDisponible("Intente nuevamente").AsignarCodigo("app.reintentar")
+	})
+
+	w := solicitar(r, "GET", "/personas", nil)
+	if c := decodificarCuerpoDeError(t, w); w.Code != http.StatusServiceUnavailable || c.Error.Codigo != "app.reintentar" {
+		t.Errorf("se obtuvo %d %+v", w.Code, c.Error)
+	}
+	if valorPanico != "sin conexión" {
+		t.Errorf("ObtenerPanico devolvió %v", valorPanico)
+	}
+}
+
+func TestRecuperarPanico(t *testing.T) {
+	var registrado error
+	r := CrearEnrutador().RegistradorDePanicos(func(r *http.Request, err error) {
+		registrado = err
+	})
+	r.GET("/personas", manejadorConPanico)
+
+	w := solicitar(r, "GET", "/personas", nil)
+	c := decodificarCuerpoDeError(t, w)
+	if w.Code != http.StatusInternalServerError || c.Error.Codigo != "apirest.panico" || c.Error.UUID == "" {
+		t.Fatalf("se obtuvo %d %+v", w.Code, c.Error)
+	}
+
+	// el error registrado posee el mismo UUID, el valor del pánico, la
+	// función donde se produjo y la pila de ejecución
+	errAPIREST, ok := ErrorEsInternoDeServidor(registrado)
+	if !ok || errAPIREST.ObtenerUUID() != c.Error.UUID || errAPIREST.ObtenerMensajeTecnico() != "pánico: índice inválido" {
+		t.Fatalf("se registró %v", registrado)
+	}
+	rastro := errAPIREST.ObtenerRastro()
+	if !strings.HasSuffix(rastro.Funcion, ".manejadorConPanico") || rastro.Archivo != "manejadores_test" ||
+		!strings.Contains(rastro.Observaciones, "runtime/debug.Stack") {
+		t.Errorf("se obtuvo el rastro %+v", rastro)
+	}
+}
+
+// manejadorConPanico produce un pánico al procesar la solicitud.
+func manejadorConPanico(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	panic("índice inválido")
+}
+
+func TestRecuperarPanicoConError(t *testing.T) {
+	var registrado error
+	errOriginal := errors.New("conexión cerrada")
+	r := CrearEnrutador().RegistradorDePanicos(func(r *http.Request, err error) {
+		registrado = err
+	})
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		panic(errOriginal)
+	})
+
+	solicitar(r, "GET", "/personas", nil)
+	if !errors.Is(registrado, errOriginal) {
+		t.Errorf("el error registrado no envuelve al error del pánico: %v", registrado)
+	}
+}
+
+func TestRecuperarPanicoConRespuestaEscrita(t *testing.T) {
+	var registrado bool
+	r := CrearEnrutador().RegistradorDePanicos(func(r *http.Request, err error) {
+		registrado = true
+	})
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("parcial"))
+		panic("después de escribir")
+	})
+
+	w := solicitar(r, "GET", "/personas", nil)
+	if w.Code != http.StatusAccepted || w.Body.String() != "parcial" || !registrado {
+		t.Errorf("se obtuvo %d %q, registrado: %v", w.Code, w.Body.String(), registrado)
+	}
+}
+
+func TestRecuperarPanicoAbortHandler(t *testing.T) {
+	r := CrearEnrutador()
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		if valor := recover(); valor != http.ErrAbortHandler {
+			t.Errorf("se esperaba que se propague http.ErrAbortHandler, se obtuvo %v", valor)
+		}
+	}()
+	solicitar(r, "GET", "/personas", nil)
+}
+
+func TestManejadorPanicoConPanico(t *testing.T) {
+	var registrados []string
+	r := CrearEnrutador().RegistradorDePanicos(func(r *http.Request, err error) {
+		errAPIREST, _ := ErrorEsAPIREST(err)
+		registrados = append(registrados, errAPIREST.ObtenerMensajeTecnico())
+	})
+	r.GET("/personas", manejadorConPanico)
+	r.ManejadorPanico(func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		panic("sin plantilla de error")
+	})
+
+	// se responde el error del pánico original
+	w := solicitar(r, "GET", "/personas", nil)
+	if c := decodificarCuerpoDeError(t, w); w.Code != http.StatusInternalServerError || c.Error.Codigo != "apirest.panico" {
+		t.Errorf("se obtuvo %d %+v", w.Code, c.Error)
+	}
+	if len(registrados) != 2 || registrados[1] != "pánico en ManejadorPanico: sin plantilla de error" {
+		t.Errorf("se registró %q", registrados)
+	}
+}
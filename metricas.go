@@ -0,0 +1,165 @@
+package apirest
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HTTPContenidoMetricas es el tipo de contenido del formato de exposición de
+// texto de Prometheus.
+const HTTPContenidoMetricas HTTPContenido = "text/plain; version=0.0.4; charset=utf-8"
+
+// limitesDeDuracion son los límites superiores (en segundos) de las cubetas
+// del histograma de duración de las solicitudes.
+var limitesDeDuracion = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// metodosDeMetricas son los métodos que se utilizan como etiqueta; el resto
+// se agrupa como "OTRO" para acotar la cantidad de series.
+var metodosDeMetricas = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+// claveDeMetrica identifica una serie por sus etiquetas.
+type claveDeMetrica struct {
+	metodo string // método HTTP
+	patron string // patrón de ruta del endpoint (vacío si la ruta no existe)
+	clase  string // clase del código de estado ("2xx", "4xx", ...)
+}
+
+// serieDeMetrica acumula las solicitudes de una serie.
+type serieDeMetrica struct {
+	cantidad uint64   // cantidad de solicitudes
+	suma     float64  // suma de las duraciones (en segundos)
+	cubetas  []uint64 // cantidad de solicitudes por cubeta (no acumulativa)
+}
+
+// metricas almacena las métricas de las solicitudes procesadas por el
+// enrutador (ver ExponerMetricas).
+type metricas struct {
+	mutex   sync.Mutex
+	series  map[claveDeMetrica]*serieDeMetrica
+	enCurso int64 // cantidad de solicitudes en curso de todo el enrutador (se actualiza de forma atómica)
+}
+
+// ExponerMetricas activa la medición de las solicitudes y registra un endpoint
+// GET en la ruta recibida que responde las métricas en el formato de
+// exposición de texto de Prometheus:
+// 	apirest_solicitudes_total{metodo, patron, clase}: contador de solicitudes.
+// 	apirest_solicitudes_duracion_segundos{metodo, patron, clase}: histograma
+// 	de la duración de las solicitudes.
+// 	apirest_solicitudes_en_curso: solicitudes que se están procesando (es un
+// 	único valor para todo el enrutador, sin etiquetas).
+// Las solicitudes se identifican por su patrón de ruta ("/personas/{id}") y no
+// por la ruta recibida, para acotar la cantidad de series.
+// Ejemplo:
+// 	r.ExponerMetricas("/metrics")
+func (o *enrutador) ExponerMetricas(ruta string) *endpoint {
+	if o.metricas == nil {
+		o.metricas = &metricas{series: make(map[claveDeMetrica]*serieDeMetrica)}
+	}
+
+	return o.GET(ruta, o.metricas.exponer)
+}
+
+// iniciar registra el inicio de una solicitud y devuelve la función que
+// registra su finalización.
+func (o *metricas) iniciar(w *escritorDeRespuesta, r *http.Request, acceso *datosDeAcceso) func() {
+	inicio := time.Now()
+	atomic.AddInt64(&o.enCurso, 1)
+
+	return func() {
+		atomic.AddInt64(&o.enCurso, -1)
+
+		estado := estadoRespondido(w)
+		metodo := r.Method
+		if !contieneTexto(metodosDeMetricas, metodo) {
+			metodo = "OTRO"
+		}
+		o.registrar(claveDeMetrica{metodo, acceso.patron, fmt.Sprintf("%dxx", estado/100)}, time.Since(inicio))
+	}
+}
+
+// registrar acumula la duración de la solicitud en su serie.
+func (o *metricas) registrar(clave claveDeMetrica, duracion time.Duration) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	serie, ok := o.series[clave]
+	if !ok {
+		serie = &serieDeMetrica{cubetas: make([]uint64, len(limitesDeDuracion))}
+		o.series[clave] = serie
+	}
+
+	segundos := duracion.Seconds()
+	serie.cantidad++
+	serie.suma += segundos
+	for i, limite := range limitesDeDuracion {
+		if segundos <= limite {
+			serie.cubetas[i]++
+			break
+		}
+	}
+}
+
+// exponer responde las métricas en el formato de exposición de texto.
+func (o *metricas) exponer(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	var b bytes.Buffer
+
+	o.mutex.Lock()
+	claves := make([]claveDeMetrica, 0, len(o.series))
+	for clave := range o.series {
+		claves = append(claves, clave)
+	}
+	sort.Slice(claves, func(i, j int) bool {
+		if claves[i].patron != claves[j].patron {
+			return claves[i].patron < claves[j].patron
+		}
+		if claves[i].metodo != claves[j].metodo {
+			return claves[i].metodo < claves[j].metodo
+		}
+		return claves[i].clase < claves[j].clase
+	})
+
+	b.WriteString("# HELP apirest_solicitudes_total Cantidad de solicitudes procesadas.\n")
+	b.WriteString("# TYPE apirest_solicitudes_total counter\n")
+	for _, clave := range claves {
+		fmt.Fprintf(&b, "apirest_solicitudes_total{%s} %d\n", clave.etiquetas(), o.series[clave].cantidad)
+	}
+
+	b.WriteString("# HELP apirest_solicitudes_duracion_segundos Duración de las solicitudes en segundos.\n")
+	b.WriteString("# TYPE apirest_solicitudes_duracion_segundos histogram\n")
+	for _, clave := range claves {
+		serie, etiquetas := o.series[clave], clave.etiquetas()
+		var acumulado uint64
+		for i, limite := range limitesDeDuracion {
+			acumulado += serie.cubetas[i]
+			fmt.Fprintf(&b, "apirest_solicitudes_duracion_segundos_bucket{%s,le=\"%g\"} %d\n", etiquetas, limite, acumulado)
+		}
+		fmt.Fprintf(&b, "apirest_solicitudes_duracion_segundos_bucket{%s,le=\"+Inf\"} %d\n", etiquetas, serie.cantidad)
+		fmt.Fprintf(&b, "apirest_solicitudes_duracion_segundos_sum{%s} %g\n", etiquetas, serie.suma)
+		fmt.Fprintf(&b, "apirest_solicitudes_duracion_segundos_count{%s} %d\n", etiquetas, serie.cantidad)
+	}
+	o.mutex.Unlock()
+
+	b.WriteString("# HELP apirest_solicitudes_en_curso Cantidad de solicitudes que se están procesando en el enrutador.\n")
+	b.WriteString("# TYPE apirest_solicitudes_en_curso gauge\n")
+	fmt.Fprintf(&b, "apirest_solicitudes_en_curso %d\n", atomic.LoadInt64(&o.enCurso))
+
+	return nil, HTTPResponder(w, HTTPEstadoOk, HTTPContenidoMetricas, nil, b.String())
+}
+
+// etiquetas devuelve las etiquetas de la serie en el formato de exposición.
+func (o claveDeMetrica) etiquetas() string {
+	return fmt.Sprintf(`metodo="%s",patron="%s",clase="%s"`,
+		escaparEtiqueta(o.metodo), escaparEtiqueta(o.patron), escaparEtiqueta(o.clase))
+}
+
+// escaparEtiqueta escapa el valor de una etiqueta (barras invertidas, comillas
+// y saltos de línea).
+func escaparEtiqueta(valor string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(valor)
+}
@@ -0,0 +1,186 @@
+package apirest
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// lineasDeMetricas devuelve las líneas de las métricas expuestas (sin los
+// comentarios) que comienzan con el prefijo recibido.
+func lineasDeMetricas(cuerpo, prefijo string) []string {
+	var lineas []string
+	for _, linea := range strings.Split(cuerpo, "\n") {
+		if strings.HasPrefix(linea, prefijo) {
+			lineas = append(lineas, linea)
+		}
+	}
+
+	return lineas
+}
+
+func TestExponerMetricas(t *testing.T) {
+	r := CrearEnrutador()
+	r.ExponerMetricas("/metrics")
+	r.GET("/personas/{id}", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return "persona", nil
+	})
+	r.POST("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, errors.New("sin conexión")
+	})
+	r.Manejar("PROPFIND", "/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, nil
+	})
+
+	for _, solicitud := range [][2]string{
+		{"GET", "/personas/5"}, {"GET", "/personas/7"}, {"POST", "/personas"}, {"PROPFIND", "/personas"},
+		{"GET", "/inexistente/1"}, {"GET", "/inexistente/2"}, {"DELETE", "/personas/5"},
+	} {
+		solicitar(r, solicitud[0], solicitud[1], nil)
+	}
+
+	w := solicitar(r, "GET", "/metrics", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != HTTPContenidoMetricas.obtenerTexto() {
+		t.Fatalf("se obtuvo %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	cuerpo := w.Body.String()
+
+	// las series se ordenan por patrón, método y clase
+	esperadas := []string{
+		`apirest_solicitudes_total{metodo="GET",patron="",clase="4xx"} 2`,
+		`apirest_solicitudes_total{metodo="OTRO",patron="/personas",clase="2xx"} 1`,
+		`apirest_solicitudes_total{metodo="POST",patron="/personas",clase="5xx"} 1`,
+		`apirest_solicitudes_total{metodo="DELETE",patron="/personas/{id}",clase="4xx"} 1`,
+		`apirest_solicitudes_total{metodo="GET",patron="/personas/{id}",clase="2xx"} 2`,
+	}
+	if totales := lineasDeMetricas(cuerpo, "apirest_solicitudes_total{"); strings.Join(totales, "\n") != strings.Join(esperadas, "\n") {
+		t.Errorf("se obtuvo:\n%s", strings.Join(totales, "\n"))
+	}
+	for _, linea := range []string{
+		"# TYPE apirest_solicitudes_total counter",
+		"# TYPE apirest_solicitudes_duracion_segundos histogram",
+		"# TYPE apirest_solicitudes_en_curso gauge",
+		`apirest_solicitudes_duracion_segundos_count{metodo="GET",patron="/personas/{id}",clase="2xx"} 2`,
+		`apirest_solicitudes_duracion_segundos_bucket{metodo="GET",patron="/personas/{id}",clase="2xx",le="+Inf"} 2`,
+		"apirest_solicitudes_en_curso 1",
+	} {
+		if !strings.Contains(cuerpo, linea+"\n") {
+			t.Errorf("no se encontró la línea %q", linea)
+		}
+	}
+
+	// las rutas recibidas no se utilizan como etiqueta
+	if strings.Contains(cuerpo, "/inexistente") || strings.Contains(cuerpo, "/personas/5") {
+		t.Errorf("las métricas contienen rutas recibidas:\n%s", cuerpo)
+	}
+	// la solicitud de las métricas se registra una vez respondida
+	w = solicitar(r, "GET", "/metrics", nil)
+	if !strings.Contains(w.Body.String(), `apirest_solicitudes_total{metodo="GET",patron="/metrics",clase="2xx"} 1`) {
+		t.Errorf("no se registró la solicitud de las métricas")
+	}
+}
+
+func TestMetricasRutasInexistentes(t *testing.T) {
+	r := CrearEnrutador()
+	r.ExponerMetricas("/metrics")
+
+	for i := 0; i < 50; i++ {
+		solicitar(r, "GET", "/aleatoria/"+strings.Repeat("x", i), nil)
+	}
+
+	w := solicitar(r, "GET", "/metrics", nil)
+	totales := lineasDeMetricas(w.Body.String(), "apirest_solicitudes_total{")
+	if len(totales) != 1 || totales[0] != `apirest_solicitudes_total{metodo="GET",patron="",clase="4xx"} 50` {
+		t.Errorf("se obtuvo %v", totales)
+	}
+}
+
+func TestMetricasCubetasDelHistograma(t *testing.T) {
+	m := &metricas{series: make(map[claveDeMetrica]*serieDeMetrica)}
+	clave := claveDeMetrica{"GET", "/personas", "2xx"}
+	for _, duracion := range []time.Duration{
+		3 * time.Millisecond, 5 * time.Millisecond, 20 * time.Millisecond, 2 * time.Second, 20 * time.Second,
+	} {
+		m.registrar(clave, duracion)
+	}
+
+	w := solicitar(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { m.exponer(w, r) }), "GET", "/metrics", nil)
+	cubetas := lineasDeMetricas(w.Body.String(), "apirest_solicitudes_duracion_segundos_")
+	esperadas := []string{
+		`apirest_solicitudes_duracion_segundos_bucket{metodo="GET",patron="/personas",clase="2xx",le="0.005"} 2`,
+		`apirest_solicitudes_duracion_segundos_bucket{metodo="GET",patron="/personas",clase="2xx",le="0.01"} 2`,
+		`apirest_solicitudes_duracion_segundos_bucket{metodo="GET",patron="/personas",clase="2xx",le="0.025"} 3`,
+		`apirest_solicitudes_duracion_segundos_bucket{metodo="GET",patron="/personas",clase="2xx",le="0.05"} 3`,
+		`apirest_solicitudes_duracion_segundos_bucket{metodo="GET",patron="/personas",clase="2xx",le="0.1"} 3`,
+		`apirest_solicitudes_duracion_segundos_bucket{metodo="GET",patron="/personas",clase="2xx",le="0.25"} 3`,
+		`apirest_solicitudes_duracion_segundos_bucket{metodo="GET",patron="/personas",clase="2xx",le="0.5"} 3`,
+		`apirest_solicitudes_duracion_segundos_bucket{metodo="GET",patron="/personas",clase="2xx",le="1"} 3`,
+		`apirest_solicitudes_duracion_segundos_bucket{metodo="GET",patron="/personas",clase="2xx",le="2.5"} 4`,
+		`apirest_solicitudes_duracion_segundos_bucket{metodo="GET",patron="/personas",clase="2xx",le="5"} 4`,
+		`apirest_solicitudes_duracion_segundos_bucket{metodo="GET",patron="/personas",clase="2xx",le="10"} 4`,
+		`apirest_solicitudes_duracion_segundos_bucket{metodo="GET",patron="/personas",clase="2xx",le="+Inf"} 5`,
+		`apirest_solicitudes_duracion_segundos_sum{metodo="GET",patron="/personas",clase="2xx"} 22.028`,
+		`apirest_solicitudes_duracion_segundos_count{metodo="GET",patron="/personas",clase="2xx"} 5`,
+	}
+	if strings.Join(cubetas, "\n") != strings.Join(esperadas, "\n") {
+		t.Errorf("se obtuvo:\n%s", strings.Join(cubetas, "\n"))
+	}
+}
+
+func TestMetricasRespuestaAbortada(t *testing.T) {
+	r := CrearEnrutador()
+	r.ExponerMetricas("/metrics")
+	r.GET("/abortada", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		panic(http.ErrAbortHandler)
+	})
+
+	// el pánico se propaga al servidor, como en TestRecuperarPanicoAbortHandler
+	func() {
+		defer func() { recover() }()
+		solicitar(r, "GET", "/abortada", nil)
+	}()
+
+	// la respuesta no escrita se registra como 5xx, al igual que en el
+	// registro de accesos
+	w := solicitar(r, "GET", "/metrics", nil)
+	if linea := `apirest_solicitudes_total{metodo="GET",patron="/abortada",clase="5xx"} 1`; !strings.Contains(w.Body.String(), linea+"\n") {
+		t.Errorf("no se encontró la línea %q en:\n%s", linea, w.Body.String())
+	}
+}
+
+func TestMetricasSolicitudesEnCurso(t *testing.T) {
+	iniciada, liberar := make(chan struct{}), make(chan struct{})
+	r := CrearEnrutador()
+	r.ExponerMetricas("/metrics")
+	r.GET("/lenta", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		close(iniciada)
+		<-liberar
+		return nil, nil
+	})
+
+	finalizada := make(chan struct{})
+	go func() {
+		solicitar(r, "GET", "/lenta", nil)
+		close(finalizada)
+	}()
+	<-iniciada
+
+	// la solicitud lenta y la solicitud de las métricas
+	if w := solicitar(r, "GET", "/metrics", nil); !strings.Contains(w.Body.String(), "apirest_solicitudes_en_curso 2\n") {
+		t.Errorf("se obtuvo:\n%s", w.Body.String())
+	}
+
+	close(liberar)
+	<-finalizada
+	if w := solicitar(r, "GET", "/metrics", nil); !strings.Contains(w.Body.String(), "apirest_solicitudes_en_curso 1\n") {
+		t.Errorf("se obtuvo:\n%s", w.Body.String())
+	}
+}
+
+func TestEscaparEtiqueta(t *testing.T) {
+	if valor := escaparEtiqueta("a\\b\"c\nd"); valor != `a\\b\"c\nd` {
+		t.Errorf("se obtuvo %q", valor)
+	}
+}
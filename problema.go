@@ -0,0 +1,111 @@
+package apirest
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// HTTPContenidoApplicationProblemJSON es el tipo de contenido de los errores
+// según el formato "application/problem+json" (RFC 7807).
+const HTTPContenidoApplicationProblemJSON HTTPContenido = "application/problem+json; charset=utf-8"
+
+// esModoDepuracion determina que la serialización de los errores incluye el
+// mensaje técnico y los rastros (ver ErrorModoDepuracion).
+var esModoDepuracion bool
+
+// ErrorModoDepuracion determina que la serialización JSON de los errores
+// incluye el mensaje técnico y los rastros del error. Sólo debe activarse en
+// entornos de desarrollo, al iniciar la aplicación.
+func ErrorModoDepuracion(activo bool) {
+	esModoDepuracion = activo
+}
+
+// problemaJSON es la representación de un error según el formato
+// "application/problem+json" (RFC 7807), junto con los miembros de extensión
+// propios del paquete.
+type problemaJSON struct {
+	Tipo      string `json:"type"`
+	Titulo    string `json:"title"`
+	Estado    int    `json:"status"`
+	Detalle   string `json:"detail,omitempty"`
+	Instancia string `json:"instance,omitempty"`
+
+	Codigo             string         `json:"codigo,omitempty"`
+	ValoresAdicionales []string       `json:"valoresAdicionales,omitempty"`
+	UUID               string         `json:"uuid,omitempty"`
+	Campos             []errorDeCampo `json:"campos,omitempty"`
+	MensajeTecnico     string         `json:"mensajeTecnico,omitempty"` // sólo en modo depuración
+	Rastros            []errorRastro  `json:"rastros,omitempty"`        // sólo en modo depuración
+}
+
+// MarshalJSON serializa el error según el formato "application/problem+json"
+// (RFC 7807). Los valores se obtienen del primer error de la cadena que posea
+// código de estado HTTP (ver ErrorEsAPIREST).
+// Ejemplo:
+// 	{
+// 		"type": "about:blank",
+// 		"title": "Not Found",
+// 		"status": 404,
+// 		"detail": "La persona 5 no existe",
+// 		"instance": "urn:uuid:8f77a891-597e-4eed-88f0-16cea5a51008",
+// 		"codigo": "personas.inexistente",
+// 		"uuid": "8f77a891-597e-4eed-88f0-16cea5a51008"
+// 	}
+func (o *errorAPIREST) MarshalJSON() ([]byte, error) {
+	errAPIREST, ok := ErrorEsAPIREST(o)
+	if !ok {
+		errAPIREST = &errorAPIREST{estadoHTTP: HTTPEstadoErrorInternoDeServidor, mensaje: o.mensaje}
+	}
+
+	var p = problemaJSON{
+		Tipo:               "about:blank",
+		Titulo:             errAPIREST.estadoHTTP.String(),
+		Estado:             errAPIREST.estadoHTTP.obtenerEntero(),
+		Detalle:            errAPIREST.mensaje,
+		Codigo:             errAPIREST.codigo,
+		ValoresAdicionales: errAPIREST.valoresAdicionales,
+		UUID:               errAPIREST.uuid,
+		Campos:             errAPIREST.erroresDeCampos,
+	}
+	if p.UUID != "" {
+		p.Instancia = "urn:uuid:" + p.UUID
+	}
+	if esModoDepuracion {
+		p.MensajeTecnico = errAPIREST.mensajeTecnico
+		p.Rastros = ErrorObtenerRastros(o)
+	}
+
+	return json.Marshal(p)
+}
+
+// HTTPResponderError responde el error recibido según el formato
+// "application/problem+json" (RFC 7807). El código de estado HTTP se obtiene
+// del primer error de tipo errorAPIREST de la cadena de errores; si no existe,
+// se responde como error interno del servidor (500) sin exponer el mensaje del
+// error original.
+func HTTPResponderError(w http.ResponseWriter, err error) error {
+	errAPIREST, ok := ErrorEsAPIREST(err)
+	if !ok {
+		errAPIREST = ErrorNuevoInternoDeServidor("Error interno del servidor").AsignarCodigo("apirest.errorInternoDeServidor")
+		errAPIREST.errAnterior = err
+	}
+
+	// serializar el error recibido (si es de tipo errorAPIREST), para incluir
+	// todos sus rastros
+	var errSerializable = errAPIREST
+	if e, esAPIREST := err.(*errorAPIREST); ok && esAPIREST {
+		errSerializable = e
+	}
+
+	cuerpo, err := json.Marshal(errSerializable)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", HTTPContenidoApplicationProblemJSON.obtenerTexto())
+	cabeceraReintentarDespues(w, errAPIREST)
+	w.WriteHeader(errAPIREST.estadoHTTP.obtenerEntero())
+	_, err = w.Write(cuerpo)
+
+	return err
+}
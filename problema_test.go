@@ -0,0 +1,122 @@
+package apirest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// decodificarProblema decodifica un error serializado según el formato
+// "application/problem+json".
+func decodificarProblema(t *testing.T, cuerpo []byte) problemaJSON {
+	t.Helper()
+
+	var p problemaJSON
+	if err := json.Unmarshal(cuerpo, &p); err != nil {
+		t.Fatalf("el cuerpo %q no es un problema JSON: %v", cuerpo, err)
+	}
+
+	return p
+}
+
+func TestErrorMarshalJSON(t *testing.T) {
+	err := ErrorNuevoNoEncontrado(`La persona "Ana" no existe`).
+		AsignarCodigo("personas.inexistente").
+		AsignarValoresAdicionales("Ana").
+		AsignarMensajeTecnico("select sin filas")
+	err.uuid = "8f77a891-597e-4eed-88f0-16cea5a51008"
+
+	cuerpo, errJSON := json.Marshal(err)
+	if errJSON != nil {
+		t.Fatal(errJSON)
+	}
+	p := decodificarProblema(t, cuerpo)
+	esperado := problemaJSON{
+		Tipo:               "about:blank",
+		Titulo:             "Not Found",
+		Estado:             404,
+		Detalle:            `La persona "Ana" no existe`,
+		Instancia:          "urn:uuid:8f77a891-597e-4eed-88f0-16cea5a51008",
+		Codigo:             "personas.inexistente",
+		ValoresAdicionales: []string{"Ana"},
+		UUID:               "8f77a891-597e-4eed-88f0-16cea5a51008",
+	}
+	if !reflect.DeepEqual(p, esperado) {
+		t.Errorf("se obtuvo %+v, se esperaba %+v", p, esperado)
+	}
+}
+
+func TestErrorMarshalJSONModoDepuracion(t *testing.T) {
+	ErrorModoDepuracion(true)
+	defer ErrorModoDepuracion(false)
+
+	err := ErrorEnvolverMalRequerimiento(ErrorNuevoRastro(errors.New("origen")), "Solicitud inválida").
+		AsignarMensajeTecnico("detalle técnico")
+	cuerpo, _ := json.Marshal(err)
+	p := decodificarProblema(t, cuerpo)
+	if p.MensajeTecnico != "detalle técnico" || len(p.Rastros) != 2 || p.Rastros[0].Archivo != "problema_test" {
+		t.Errorf("se obtuvo %s", cuerpo)
+	}
+
+	ErrorModoDepuracion(false)
+	cuerpo, _ = json.Marshal(err)
+	if p := decodificarProblema(t, cuerpo); p.MensajeTecnico != "" || len(p.Rastros) != 0 {
+		t.Errorf("se expuso información de depuración: %s", cuerpo)
+	}
+}
+
+func TestHTTPResponderError(t *testing.T) {
+	pruebas := []struct {
+		nombre  string
+		err     error
+		estado  int
+		codigo  string
+		detalle string
+	}{
+		{"errorAPIREST", ErrorNuevoConflicto("Ya existe").AsignarCodigo("personas.existente"), 409, "personas.existente", "Ya existe"},
+		{"errorAPIREST envuelto", fmt.Errorf("crear: %w", ErrorNuevoConflicto("Ya existe")), 409, "", "Ya existe"},
+		{"error sin tipo", errors.New("contraseña de la base de datos"), 500, "apirest.errorInternoDeServidor", "Error interno del servidor"},
+	}
+	for _, p := range pruebas {
+		t.Run(p.nombre, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if err := HTTPResponderError(w, p.err); err != nil {
+				t.Fatal(err)
+			}
+
+			problema := decodificarProblema(t, w.Body.Bytes())
+			if w.Code != p.estado || problema.Estado != p.estado || problema.Codigo != p.codigo || problema.Detalle != p.detalle {
+				t.Errorf("se obtuvo %d %+v", w.Code, problema)
+			}
+			if tipo := w.Header().Get("Content-Type"); tipo != HTTPContenidoApplicationProblemJSON.obtenerTexto() {
+				t.Errorf("tipo de contenido %q", tipo)
+			}
+		})
+	}
+}
+
+func TestHTTPResponderErrorReintentarDespues(t *testing.T) {
+	w := httptest.NewRecorder()
+	HTTPResponderError(w, ErrorNuevoServicioNoDisponible("En mantenimiento").AsignarReintentarDespues(1500*time.Millisecond))
+
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "2" {
+		t.Errorf("se obtuvo %d %v", w.Code, w.Header())
+	}
+}
+
+func TestSobreDeErrorEscapaLosMensajes(t *testing.T) {
+	r := CrearEnrutador()
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, ErrorNuevoMalRequerimiento(`El campo "nombre" es inválido: <\n>`)
+	})
+
+	w := solicitar(r, "GET", "/personas", nil)
+	if c := decodificarCuerpoDeError(t, w); c.Error.Mensaje != `El campo "nombre" es inválido: <\n>` {
+		t.Errorf("se obtuvo %q", c.Error.Mensaje)
+	}
+}
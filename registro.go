@@ -0,0 +1,217 @@
+package apirest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ConflictoDeRegistro identifica el tipo de problema detectado al registrar
+// un endpoint en el enrutador.
+type ConflictoDeRegistro int
+
+// Tipos de conflictos de registro:
+// 	ConflictoRutaInvalida        = la ruta no puede convertirse en un patrón de ruta
+// 	ConflictoComodinNoFinal      = la parte comodín no es la última parte de la ruta
+// 	ConflictoVariablesDistintas  = el patrón de ruta ya existe con otros nombres de variables
+// 	ConflictoMetodoDuplicado     = el patrón de ruta ya posee un endpoint con el mismo método
+// 	ConflictoCORSInvalido        = la configuración CORS del enrutador no es válida
+// 	ConflictoMetodoInvalido      = el método no es un método HTTP válido
+// 	ConflictoInterceptorInvalido = las opciones de un interceptor no son válidas
+const (
+	ConflictoRutaInvalida ConflictoDeRegistro = iota + 1
+	ConflictoComodinNoFinal
+	ConflictoVariablesDistintas
+	ConflictoMetodoDuplicado
+	ConflictoCORSInvalido
+	ConflictoMetodoInvalido
+	ConflictoInterceptorInvalido
+)
+
+// errorDeRegistro almacena un problema detectado al registrar un endpoint.
+type errorDeRegistro struct {
+	conflicto ConflictoDeRegistro // tipo de conflicto
+	metodo    string              // método HTTP del endpoint
+	ruta      string              // ruta ingresada por el desarrollador
+	mensaje   string              // descripción del problema
+	err       error               // error que originó el problema (es opcional)
+}
+
+// Error retorna el mensaje de error (implementa la interface error).
+func (o *errorDeRegistro) Error() string {
+	if o.metodo == "" && o.ruta == "" {
+		return o.mensaje
+	}
+	return fmt.Sprintf("[%v] %v: %v", o.metodo, o.ruta, o.mensaje)
+}
+
+// Unwrap devuelve el error que originó el problema.
+func (o *errorDeRegistro) Unwrap() error {
+	return o.err
+}
+
+// ObtenerConflicto devuelve el tipo de conflicto.
+func (o *errorDeRegistro) ObtenerConflicto() ConflictoDeRegistro {
+	return o.conflicto
+}
+
+// ObtenerMetodo devuelve el método HTTP del endpoint.
+func (o *errorDeRegistro) ObtenerMetodo() string {
+	return o.metodo
+}
+
+// ObtenerRuta devuelve la ruta del endpoint, tal como fue ingresada.
+func (o *errorDeRegistro) ObtenerRuta() string {
+	return o.ruta
+}
+
+// erroresDeRegistro agrupa todos los problemas detectados al registrar los
+// endpoints del enrutador.
+type erroresDeRegistro []*errorDeRegistro
+
+// Error retorna los mensajes de todos los errores (implementa la interface error).
+func (o erroresDeRegistro) Error() string {
+	var mensajes = make([]string, len(o))
+	for i, err := range o {
+		mensajes[i] = err.Error()
+	}
+
+	return fmt.Sprintf("existen %v errores de registro de endpoints:\n%v", len(o), strings.Join(mensajes, "\n"))
+}
+
+// Is permite que errors.Is busque el error objetivo en los errores agrupados.
+func (o erroresDeRegistro) Is(objetivo error) bool {
+	for _, err := range o {
+		if errors.Is(err, objetivo) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As permite que errors.As busque el tipo objetivo en los errores agrupados
+// (se asigna el primer error que coincida).
+func (o erroresDeRegistro) As(objetivo interface{}) bool {
+	for _, err := range o {
+		if errors.As(err, objetivo) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ObtenerErrores devuelve la lista de errores de registro.
+func (o erroresDeRegistro) ObtenerErrores() []*errorDeRegistro {
+	return o
+}
+
+// registrarError agrega un problema de registro al enrutador. En modo
+// estricto, el problema se informa inmediatamente a través de un pánico.
+func (o *enrutador) registrarError(conflicto ConflictoDeRegistro, metodo, ruta string, err error, formato string, args ...interface{}) {
+	o.agregarErrorDeRegistro(&errorDeRegistro{
+		conflicto: conflicto,
+		metodo:    metodo,
+		ruta:      ruta,
+		mensaje:   fmt.Sprintf(formato, args...),
+		err:       err,
+	})
+}
+
+// agregarErrorDeRegistro agrega el problema al enrutador, si aún no fue
+// agregado. En modo estricto, el problema se informa a través de un pánico.
+func (o *enrutador) agregarErrorDeRegistro(errRegistro *errorDeRegistro) {
+	if o.esEstricto {
+		panic(errRegistro)
+	}
+	for _, existente := range o.erroresDeRegistro {
+		if existente == errRegistro {
+			return
+		}
+	}
+
+	o.erroresDeRegistro = append(o.erroresDeRegistro, errRegistro)
+}
+
+// interceptorInvalido devuelve el interceptor creado con opciones inválidas.
+// Al encadenarlo en el enrutador, en un grupo o en un endpoint, el problema se
+// agrega al enrutador (ver Validar). Si se encadena fuera del enrutador (con
+// CrearInterceptores), el problema se informa a través de un pánico.
+func interceptorInvalido(formato string, args ...interface{}) InterceptorFunc {
+	errRegistro := &errorDeRegistro{conflicto: ConflictoInterceptorInvalido, mensaje: fmt.Sprintf(formato, args...)}
+
+	return func(ManejadorFunc) ManejadorFunc {
+		panic(errRegistro)
+	}
+}
+
+// encadenarInterceptores encadena los interceptores con la función recibida.
+// Si alguno de los interceptores es inválido, el problema se agrega al
+// enrutador y se devuelve una función que responde un error 500, para que la
+// función no se procese sin sus interceptores.
+func (o *enrutador) encadenarInterceptores(funciones []InterceptorFunc, manejadorFunc ManejadorFunc) (encadenada ManejadorFunc) {
+	defer func() {
+		valor := recover()
+		if valor == nil {
+			return
+		}
+		errRegistro, ok := valor.(*errorDeRegistro)
+		if !ok || errRegistro.conflicto != ConflictoInterceptorInvalido {
+			panic(valor)
+		}
+
+		o.agregarErrorDeRegistro(errRegistro)
+		encadenada = func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+			return nil, ErrorNuevoInternoDeServidor("Error interno del servidor").
+				AsignarCodigo("apirest.interceptorInvalido").
+				AsignarMensajeTecnico("%v", errRegistro)
+		}
+	}()
+
+	return CrearInterceptores(funciones...).Ejecutar(manejadorFunc)
+}
+
+// ModoEstricto determina que los problemas de registro de endpoints se
+// informan inmediatamente a través de un pánico, en lugar de acumularse para
+// ser devueltos por Validar.
+func (o *enrutador) ModoEstricto() *enrutador {
+	o.esEstricto = true
+	return o
+}
+
+// Validar devuelve todos los problemas detectados al registrar los endpoints y
+// los problemas de configuración CORS. Si no existen problemas, devuelve nil.
+// IniciarPorHTTP e IniciarPorHTTPS invocan este método antes de iniciar el
+// servidor.
+func (o *enrutador) Validar() error {
+	var errores = append(erroresDeRegistro(nil), o.erroresDeRegistro...)
+	if errCORS := o.validarCORS(); errCORS != nil {
+		errores = append(errores, errCORS)
+	}
+	if len(errores) == 0 {
+		return nil
+	}
+
+	return errores
+}
+
+// ErrorEsRegistro verifica que el error (o alguno de los errores agrupados
+// devueltos por Validar) sea un error de registro del tipo de conflicto
+// recibido. Los errores se buscan en la cadena de errores envueltos, por lo que
+// también se encuentran si fueron envueltos con fmt.Errorf("...: %w", err).
+func ErrorEsRegistro(err error, conflicto ConflictoDeRegistro) bool {
+	var errores erroresDeRegistro
+	if errors.As(err, &errores) {
+		for _, errRegistro := range errores {
+			if errRegistro.conflicto == conflicto {
+				return true
+			}
+		}
+		return false
+	}
+
+	var errRegistro *errorDeRegistro
+	return errors.As(err, &errRegistro) && errRegistro.conflicto == conflicto
+}
@@ -0,0 +1,135 @@
+package apirest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestConflictosDeRegistro(t *testing.T) {
+	manejador := func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, nil
+	}
+
+	pruebas := []struct {
+		nombre    string
+		registrar func(r *enrutador)
+		conflicto ConflictoDeRegistro
+	}{
+		{"ruta vacía", func(r *enrutador) { r.GET("", manejador) }, ConflictoRutaInvalida},
+		{"variable sin nombre", func(r *enrutador) { r.GET("/personas/{}", manejador) }, ConflictoRutaInvalida},
+		{"variable sin cierre", func(r *enrutador) { r.GET("/personas/{id", manejador) }, ConflictoRutaInvalida},
+		{"variable mal formada", func(r *enrutador) { r.GET("/personas/a{id}", manejador) }, ConflictoRutaInvalida},
+		{"restricción vacía", func(r *enrutador) { r.GET("/personas/{id:}", manejador) }, ConflictoRutaInvalida},
+		{"comodín no final", func(r *enrutador) { r.GET("/archivos/{ruta...}/detalle", manejador) }, ConflictoComodinNoFinal},
+		{"variables distintas", func(r *enrutador) {
+			r.GET("/personas/{id}", manejador)
+			r.PUT("/personas/{codigo}", manejador)
+		}, ConflictoVariablesDistintas},
+		{"método duplicado", func(r *enrutador) {
+			r.GET("/personas/{id}", manejador)
+			r.GET("/personas/{id}", manejador)
+		}, ConflictoMetodoDuplicado},
+		{"CORS inválido", func(r *enrutador) { r.CORSActivar().COSCredenciales(true) }, ConflictoCORSInvalido},
+		{"método inválido", func(r *enrutador) { r.Manejar("GE T", "/personas", manejador) }, ConflictoMetodoInvalido},
+	}
+	for _, p := range pruebas {
+		t.Run(p.nombre, func(t *testing.T) {
+			r := CrearEnrutador()
+			p.registrar(r)
+
+			err := r.Validar()
+			if !ErrorEsRegistro(err, p.conflicto) {
+				t.Fatalf("se esperaba el conflicto %v, se obtuvo %v", p.conflicto, err)
+			}
+			if !ErrorEsRegistro(fmt.Errorf("iniciar: %w", err), p.conflicto) {
+				t.Errorf("no se encontró el conflicto en el error envuelto")
+			}
+
+			var errRegistro *errorDeRegistro
+			if !errors.As(err, &errRegistro) || errRegistro.ObtenerConflicto() != p.conflicto {
+				t.Errorf("errors.As no encontró el error de registro: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidarSinConflictos(t *testing.T) {
+	r := CrearEnrutador()
+	r.GET("/personas/{id}", nil)
+	r.PUT("/personas/{id}", nil)
+	r.CORSActivar().CORSOrigenes("https://ejemplo.com").COSCredenciales(true)
+
+	if err := r.Validar(); err != nil {
+		t.Errorf("se obtuvo %v", err)
+	}
+}
+
+func TestValidarAgrupaLosConflictos(t *testing.T) {
+	r := CrearEnrutador()
+	r.GET("", nil)
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, nil
+	})
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return "duplicado", nil
+	})
+
+	err := r.Validar()
+	errores, ok := err.(erroresDeRegistro)
+	if !ok || len(errores.ObtenerErrores()) != 2 {
+		t.Fatalf("se esperaban 2 errores agrupados, se obtuvo %v", err)
+	}
+	if errores[1].ObtenerMetodo() != "GET" || errores[1].ObtenerRuta() != "/personas" {
+		t.Errorf("se obtuvo %v %v", errores[1].ObtenerMetodo(), errores[1].ObtenerRuta())
+	}
+	if !ErrorEsRegistro(err, ConflictoRutaInvalida) || !ErrorEsRegistro(err, ConflictoMetodoDuplicado) ||
+		ErrorEsRegistro(err, ConflictoComodinNoFinal) {
+		t.Errorf("ErrorEsRegistro no encontró los conflictos agrupados")
+	}
+
+	// errors.Is y errors.As recorren los errores agrupados
+	envuelto := fmt.Errorf("iniciar: %w", err)
+	var errRegistro *errorDeRegistro
+	if !errors.As(envuelto, &errRegistro) || errRegistro != errores[0] {
+		t.Errorf("errors.As devolvió %v, se esperaba %v", errRegistro, errores[0])
+	}
+	if !errors.Is(envuelto, errores[1]) || errors.Is(envuelto, &errorDeRegistro{}) {
+		t.Errorf("errors.Is no encontró el error agrupado")
+	}
+
+	// el endpoint duplicado no forma parte del enrutador
+	if w := solicitar(r, "GET", "/personas", nil); w.Code != http.StatusNoContent {
+		t.Errorf("se obtuvo %d", w.Code)
+	}
+}
+
+func TestErrorEsRegistroConOtrosErrores(t *testing.T) {
+	if ErrorEsRegistro(nil, ConflictoRutaInvalida) || ErrorEsRegistro(errors.New("otro"), ConflictoRutaInvalida) {
+		t.Errorf("se encontró un conflicto en un error que no es de registro")
+	}
+}
+
+func TestModoEstricto(t *testing.T) {
+	defer func() {
+		errRegistro, ok := recover().(*errorDeRegistro)
+		if !ok || errRegistro.ObtenerConflicto() != ConflictoMetodoDuplicado {
+			t.Errorf("se esperaba un pánico con el conflicto, se obtuvo %v", errRegistro)
+		}
+	}()
+
+	r := CrearEnrutador().ModoEstricto()
+	r.GET("/personas", nil)
+	r.GET("/personas", nil)
+}
+
+func TestIniciarConConflictos(t *testing.T) {
+	r := CrearEnrutador()
+	r.GET("/personas/{id", nil)
+
+	// no se inicia el servidor: se devuelven los problemas de registro
+	if err := r.IniciarPorHTTP("0"); !ErrorEsRegistro(err, ConflictoRutaInvalida) {
+		t.Errorf("se obtuvo %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package apirest
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// restriccion valida el valor recibido en una parte variable del patrón de
+// ruta. Las restricciones se indican a continuación del nombre de la variable:
+// 	"/personas/{id:int}"
+// 	"/articulos/{slug:[a-z-]+}"
+// 	"/sesiones/{uuid:uuid}"
+// 	"/agenda/{fecha:date}"
+// Las expresiones regulares no pueden contener "/", ya que cada restricción
+// valida una única parte de la ruta.
+type restriccion struct {
+	expresion string            // expresión de la restricción, tal como fue ingresada ("int", "[a-z-]+", ...)
+	validar   func(string) bool // función que valida el valor recibido
+}
+
+// formatoFecha es el formato de las variables de ruta con restricción "date".
+const formatoFecha = "2006-01-02"
+
+var expresionUUID = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// restriccionesPredefinidas almacena las restricciones que se identifican
+// por su nombre. Cualquier otra restricción es tratada como una expresión
+// regular.
+var restriccionesPredefinidas = map[string]func(string) bool{
+	"int": func(s string) bool {
+		_, err := strconv.ParseInt(s, 10, 64)
+		return err == nil
+	},
+	"uuid": expresionUUID.MatchString,
+	"date": func(s string) bool {
+		_, err := time.Parse(formatoFecha, s)
+		return err == nil
+	},
+}
+
+// nuevaRestriccion crea la restricción a partir de su expresión.
+func nuevaRestriccion(expresion string) (*restriccion, error) {
+	if validar, ok := restriccionesPredefinidas[expresion]; ok {
+		return &restriccion{expresion: expresion, validar: validar}, nil
+	}
+
+	er, err := regexp.Compile("^(?:" + expresion + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("la restricción %v no es una expresión regular válida: %v", expresion, err)
+	}
+
+	return &restriccion{expresion: expresion, validar: er.MatchString}, nil
+}
+
+// verificarBarrasEnVariables verifica que las partes variables de la ruta no
+// contengan barras. La ruta se divide en partes antes de interpretar las
+// restricciones, por lo que una expresión regular no puede contener "/".
+func verificarBarrasEnVariables(ruta string) error {
+	var nivel int
+	for _, c := range ruta {
+		switch {
+		case c == '{':
+			nivel++
+		case c == '}' && nivel > 0:
+			nivel--
+		case c == '/' && nivel > 0:
+			return fmt.Errorf("las partes variables (incluidas sus restricciones) no pueden contener '/'")
+		}
+	}
+
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// Obtener los valores de las variables de ruta con su tipo.
+
+// ObtenerVariableTexto devuelve el valor de la variable de ruta solicitada.
+func ObtenerVariableTexto(r *http.Request, nombre string) (string, error) {
+	valor, ok := ObtenerVariablesDeRuta(r)[nombre]
+	if !ok {
+		return "", ErrorNuevoMalRequerimiento("La variable de ruta %v es inexistente", nombre).AsignarCodigo("apirest.variableInexistente")
+	}
+
+	return valor, nil
+}
+
+// ObtenerVariableEntero devuelve el valor de la variable de ruta solicitada
+// convertido a entero.
+func ObtenerVariableEntero(r *http.Request, nombre string) (int, error) {
+	valor, err := ObtenerVariableTexto(r, nombre)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := strconv.Atoi(valor)
+	if err != nil {
+		return 0, ErrorNuevoMalRequerimiento("La variable de ruta %v no es un número entero", nombre).AsignarCodigo("apirest.variableInvalida").AsignarMensajeTecnico("%v", err)
+	}
+
+	return n, nil
+}
+
+// ObtenerVariableUUID devuelve el valor de la variable de ruta solicitada,
+// verificando que sea un identificador único universal.
+func ObtenerVariableUUID(r *http.Request, nombre string) (string, error) {
+	valor, err := ObtenerVariableTexto(r, nombre)
+	if err != nil {
+		return "", err
+	}
+
+	if !expresionUUID.MatchString(valor) {
+		return "", ErrorNuevoMalRequerimiento("La variable de ruta %v no es un UUID", nombre).AsignarCodigo("apirest.variableInvalida")
+	}
+
+	return valor, nil
+}
+
+// ObtenerVariableFecha devuelve el valor de la variable de ruta solicitada
+// convertido a fecha (formato: "aaaa-mm-dd").
+func ObtenerVariableFecha(r *http.Request, nombre string) (time.Time, error) {
+	valor, err := ObtenerVariableTexto(r, nombre)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	fecha, err := time.Parse(formatoFecha, valor)
+	if err != nil {
+		return time.Time{}, ErrorNuevoMalRequerimiento("La variable de ruta %v no es una fecha", nombre).AsignarCodigo("apirest.variableInvalida").AsignarMensajeTecnico("%v", err)
+	}
+
+	return fecha, nil
+}
@@ -0,0 +1,134 @@
+package apirest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRestricciones(t *testing.T) {
+	pruebas := []struct {
+		expresion string
+		valor     string
+		valido    bool
+	}{
+		{"int", "42", true},
+		{"int", "-7", true},
+		{"int", "4a", false},
+		{"int", "", false},
+		{"uuid", "0f8fad5b-d9cb-469f-a165-70867728950e", true},
+		{"uuid", "0f8fad5b-d9cb-469f-a165", false},
+		{"date", "2021-10-31", true},
+		{"date", "2021-13-01", false},
+		{"[a-z-]+", "hola-mundo", true},
+		{"[a-z-]+", "Hola", false},
+		{"a|b", "a", true},
+		{"a|b", "ab", false}, // la expresión regular debe coincidir con todo el valor
+	}
+	for _, p := range pruebas {
+		r, err := nuevaRestriccion(p.expresion)
+		if err != nil {
+			t.Fatalf("%s: %v", p.expresion, err)
+		}
+		if r.validar(p.valor) != p.valido {
+			t.Errorf("%s(%q): se esperaba %v", p.expresion, p.valor, p.valido)
+		}
+	}
+
+	if _, err := nuevaRestriccion("[a-z"); err == nil {
+		t.Errorf("se esperaba un error con una expresión regular inválida")
+	}
+}
+
+func TestRestriccionInvalidaAlRegistrar(t *testing.T) {
+	r := CrearEnrutador()
+	r.GET("/articulos/{slug:[a-z}", nil)
+
+	if err := r.Validar(); !ErrorEsRegistro(err, ConflictoRutaInvalida) {
+		t.Errorf("se esperaba un conflicto de ruta inválida, se obtuvo %v", err)
+	}
+}
+
+func TestRestriccionConBarraAlRegistrar(t *testing.T) {
+	for _, ruta := range []string{"/fechas/{fecha:[0-9]{4}/[0-9]{2}}", "/archivos/{nombre:[a-z/]+}"} {
+		r := CrearEnrutador()
+		r.GET(ruta, func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+			return nil, nil
+		})
+
+		if err := r.Validar(); !ErrorEsRegistro(err, ConflictoRutaInvalida) {
+			t.Errorf("%v: se esperaba un conflicto de ruta inválida, se obtuvo %v", ruta, err)
+		}
+	}
+}
+
+func TestRestriccionesAlDespachar(t *testing.T) {
+	r := CrearEnrutador()
+	responderPatron := func(patron string) ManejadorFunc {
+		return func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+			return patron, nil
+		}
+	}
+	r.GET("/personas/{id:int}", responderPatron("id"))
+	r.GET("/articulos/{slug:[a-z-]+}", responderPatron("slug"))
+	r.GET("/agenda/{fecha:date}", responderPatron("fecha"))
+
+	pruebas := []struct {
+		ruta   string
+		estado int
+	}{
+		{"/personas/5", http.StatusOK},
+		{"/personas/cinco", http.StatusNotFound},
+		{"/articulos/hola-mundo", http.StatusOK},
+		{"/articulos/Hola_Mundo", http.StatusNotFound},
+		{"/agenda/2021-10-31", http.StatusOK},
+		{"/agenda/mañana", http.StatusNotFound},
+	}
+	for _, p := range pruebas {
+		if w := solicitar(r, "GET", p.ruta, nil); w.Code != p.estado {
+			t.Errorf("%s: se obtuvo %d, se esperaba %d", p.ruta, w.Code, p.estado)
+		}
+	}
+}
+
+func TestObtenerVariablesConTipo(t *testing.T) {
+	var (
+		id     int
+		uuid   string
+		fecha  time.Time
+		errID  error
+		errUID error
+		errFec error
+	)
+	r := CrearEnrutador()
+	r.GET("/{id}/{uuid}/{fecha}", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		id, errID = ObtenerVariableEntero(r, "id")
+		uuid, errUID = ObtenerVariableUUID(r, "uuid")
+		fecha, errFec = ObtenerVariableFecha(r, "fecha")
+		return nil, nil
+	})
+
+	solicitar(r, "GET", "/5/0f8fad5b-d9cb-469f-a165-70867728950e/2021-10-31", nil)
+	if errID != nil || errUID != nil || errFec != nil {
+		t.Fatalf("errores inesperados: %v, %v, %v", errID, errUID, errFec)
+	}
+	if id != 5 || uuid != "0f8fad5b-d9cb-469f-a165-70867728950e" || !fecha.Equal(time.Date(2021, 10, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("se obtuvo %v, %v, %v", id, uuid, fecha)
+	}
+
+	solicitar(r, "GET", "/cinco/no-es-uuid/31-10-2021", nil)
+	for _, err := range []error{errID, errUID, errFec} {
+		if errAPIREST, ok := ErrorEsMalRequerimiento(err); !ok || errAPIREST.ObtenerCodigo() != "apirest.variableInvalida" {
+			t.Errorf("se esperaba un error apirest.variableInvalida, se obtuvo %v", err)
+		}
+	}
+
+	r.GET("/sin-variables", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		_, errID = ObtenerVariableTexto(r, "id")
+		return nil, nil
+	})
+	solicitar(r, "GET", "/sin-variables", nil)
+	if errAPIREST, ok := ErrorEsMalRequerimiento(errID); !ok || errAPIREST.ObtenerCodigo() != "apirest.variableInexistente" {
+		t.Errorf("se esperaba un error apirest.variableInexistente, se obtuvo %v", errID)
+	}
+}
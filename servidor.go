@@ -0,0 +1,100 @@
+package apirest
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// OpcionesServidor establece la configuración del servidor HTTP que utiliza
+// el enrutador al iniciar por HTTP o HTTPS. Los valores cero conservan el
+// comportamiento por defecto del paquete net/http (sin límites de tiempo).
+type OpcionesServidor struct {
+	TiempoDeLectura           time.Duration // tiempo máximo para leer la solicitud completa (incluido el cuerpo)
+	TiempoDeLecturaDeCabecera time.Duration // tiempo máximo para leer la cabecera de la solicitud
+	TiempoDeEscritura         time.Duration // tiempo máximo para escribir la respuesta
+	TiempoDeInactividad       time.Duration // tiempo máximo de espera de la próxima solicitud (keep-alive)
+	MaximoBytesDeCabecera     int           // tamaño máximo de la cabecera de la solicitud
+	ConfiguracionTLS          *tls.Config   // configuración TLS (sólo para HTTPS)
+
+	// DetenerConSenales determina que el servidor escucha las señales SIGINT y
+	// SIGTERM para detenerse ordenadamente: deja de aceptar conexiones nuevas
+	// y espera que finalicen las solicitudes en curso.
+	DetenerConSenales bool
+
+	// TiempoDeDetencion es el plazo máximo para finalizar las solicitudes en
+	// curso al recibir una señal de detención. Por defecto: 30 segundos.
+	TiempoDeDetencion time.Duration
+}
+
+// servidor almacena el servidor HTTP en ejecución, para poder detenerlo.
+type servidor struct {
+	opciones OpcionesServidor
+	mutex    sync.Mutex
+	http     *http.Server
+	detenido chan struct{} // se cierra cuando finaliza la detención del servidor
+}
+
+// ConfigurarServidor establece la configuración del servidor HTTP. Debe
+// invocarse antes de iniciar el servidor.
+// Ejemplo:
+// 	r.ConfigurarServidor(apirest.OpcionesServidor{
+// 		TiempoDeLectura:   5 * time.Second,
+// 		TiempoDeEscritura: 10 * time.Second,
+// 		DetenerConSenales: true,
+// 	})
+func (o *enrutador) ConfigurarServidor(opciones OpcionesServidor) *enrutador {
+	o.servidor.mutex.Lock()
+	defer o.servidor.mutex.Unlock()
+
+	o.servidor.opciones = opciones
+	return o
+}
+
+// Detener detiene ordenadamente el servidor: deja de aceptar conexiones nuevas
+// y espera que finalicen las solicitudes en curso, o que finalice el contexto
+// recibido. Si el servidor no fue iniciado, no realiza ninguna acción.
+func (o *enrutador) Detener(ctx context.Context) error {
+	o.servidor.mutex.Lock()
+	srv, detenido := o.servidor.http, o.servidor.detenido
+	o.servidor.mutex.Unlock()
+
+	if srv == nil {
+		return nil
+	}
+
+	err := srv.Shutdown(ctx)
+
+	o.servidor.mutex.Lock()
+	select {
+	case <-detenido:
+	default:
+		close(detenido)
+	}
+	o.servidor.mutex.Unlock()
+
+	return err
+}
+
+// crearServidorHTTP crea el servidor HTTP con las opciones configuradas.
+func (o *enrutador) crearServidorHTTP(direccion string) (*http.Server, chan struct{}) {
+	o.servidor.mutex.Lock()
+	defer o.servidor.mutex.Unlock()
+
+	opciones := o.servidor.opciones
+	o.servidor.http = &http.Server{
+		Addr:              direccion,
+		Handler:           o,
+		TLSConfig:         opciones.ConfiguracionTLS,
+		ReadTimeout:       opciones.TiempoDeLectura,
+		ReadHeaderTimeout: opciones.TiempoDeLecturaDeCabecera,
+		WriteTimeout:      opciones.TiempoDeEscritura,
+		IdleTimeout:       opciones.TiempoDeInactividad,
+		MaxHeaderBytes:    opciones.MaximoBytesDeCabecera,
+	}
+	o.servidor.detenido = make(chan struct{})
+
+	return o.servidor.http, o.servidor.detenido
+}
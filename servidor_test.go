@@ -0,0 +1,140 @@
+package apirest
+
+import (
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestConfigurarServidor(t *testing.T) {
+	configuracionTLS := &tls.Config{MinVersion: tls.VersionTLS12}
+	r := CrearEnrutador().ConfigurarServidor(OpcionesServidor{
+		TiempoDeLectura:           1 * time.Second,
+		TiempoDeLecturaDeCabecera: 2 * time.Second,
+		TiempoDeEscritura:         3 * time.Second,
+		TiempoDeInactividad:       4 * time.Second,
+		MaximoBytesDeCabecera:     4096,
+		ConfiguracionTLS:          configuracionTLS,
+	})
+
+	srv, _ := r.crearServidorHTTP(":8080")
+	if srv.Addr != ":8080" || srv.Handler != r || srv.TLSConfig != configuracionTLS ||
+		srv.ReadTimeout != 1*time.Second || srv.ReadHeaderTimeout != 2*time.Second ||
+		srv.WriteTimeout != 3*time.Second || srv.IdleTimeout != 4*time.Second || srv.MaxHeaderBytes != 4096 {
+		t.Errorf("el servidor no posee las opciones configuradas: %+v", srv)
+	}
+}
+
+func TestDetenerSinIniciar(t *testing.T) {
+	if err := CrearEnrutador().Detener(context.Background()); err != nil {
+		t.Errorf("se obtuvo %v", err)
+	}
+}
+
+func TestDetenerEsperaLasSolicitudesEnCurso(t *testing.T) {
+	iniciada, liberar := make(chan struct{}), make(chan struct{})
+	r := CrearEnrutador()
+	r.GET("/lenta", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		close(iniciada)
+		<-liberar
+		return "fin", nil
+	})
+
+	oyente, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, _ := r.crearServidorHTTP(oyente.Addr().String())
+	go srv.Serve(oyente)
+
+	cuerpos := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + oyente.Addr().String() + "/lenta")
+		if err != nil {
+			cuerpos <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		cuerpo, _ := ioutil.ReadAll(resp.Body)
+		cuerpos <- string(cuerpo)
+	}()
+	<-iniciada
+
+	detenido := make(chan error, 1)
+	go func() { detenido <- r.Detener(context.Background()) }()
+	select {
+	case err := <-detenido:
+		t.Fatalf("Detener finalizó con una solicitud en curso: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(liberar)
+	if cuerpo := <-cuerpos; cuerpo != `"fin"` {
+		t.Errorf("se obtuvo la respuesta %q", cuerpo)
+	}
+	if err := <-detenido; err != nil {
+		t.Errorf("Detener devolvió %v", err)
+	}
+}
+
+func TestDetenerVenceElPlazo(t *testing.T) {
+	iniciada, liberar := make(chan struct{}), make(chan struct{})
+	defer close(liberar)
+	r := CrearEnrutador()
+	r.GET("/lenta", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		close(iniciada)
+		<-liberar
+		return nil, nil
+	})
+
+	oyente, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, _ := r.crearServidorHTTP(oyente.Addr().String())
+	go srv.Serve(oyente)
+	go http.Get("http://" + oyente.Addr().String() + "/lenta")
+	<-iniciada
+
+	ctx, cancelar := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelar()
+	if err := r.Detener(ctx); err != context.DeadlineExceeded {
+		t.Errorf("se esperaba context.DeadlineExceeded, se obtuvo %v", err)
+	}
+}
+
+func TestIniciarPorHTTPFinalizaAlDetener(t *testing.T) {
+	r := CrearEnrutador()
+	errores := make(chan error, 1)
+	go func() { errores <- r.IniciarPorHTTP("0") }()
+
+	// esperar que se cree el servidor
+	for i := 0; ; i++ {
+		r.servidor.mutex.Lock()
+		creado := r.servidor.http != nil
+		r.servidor.mutex.Unlock()
+		if creado {
+			break
+		}
+		if i == 100 {
+			t.Fatal("no se creó el servidor")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := r.Detener(context.Background()); err != nil {
+		t.Fatalf("Detener devolvió %v", err)
+	}
+	select {
+	case err := <-errores:
+		if err != nil {
+			t.Errorf("IniciarPorHTTP devolvió %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("IniciarPorHTTP no finalizó al detener el servidor")
+	}
+}
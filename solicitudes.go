@@ -0,0 +1,66 @@
+package apirest
+
+import (
+	"context"
+	"net/http"
+)
+
+// campoIDDeSolicitud es el campo de cabecera por defecto del identificador de
+// la solicitud.
+const campoIDDeSolicitud = "X-Request-ID"
+
+// longitudMaximaIDDeSolicitud es la longitud máxima del identificador de
+// solicitud recibido; los identificadores más largos se reemplazan por uno
+// nuevo.
+const longitudMaximaIDDeSolicitud = 128
+
+// IdentificadorDeSolicitud activa el identificador de solicitud: se obtiene
+// del campo de cabecera recibido (por defecto "X-Request-ID") o, si no existe
+// o no es válido, se genera un nuevo UUID. El identificador se almacena en el
+// contexto de la solicitud (ver ObtenerIDDeSolicitud), se devuelve en el mismo
+// campo de cabecera de la respuesta y se asigna como UUID de los errores
+// respondidos que no lo poseen, para poder relacionar los registros, las
+// respuestas de error y los reportes de los clientes.
+func (o *enrutador) IdentificadorDeSolicitud(campoDeCabecera string) *enrutador {
+	if campoDeCabecera == "" {
+		campoDeCabecera = campoIDDeSolicitud
+	}
+	o.idDeSolicitud.esActivo, o.idDeSolicitud.campo = true, http.CanonicalHeaderKey(campoDeCabecera)
+
+	return o
+}
+
+// asignarIDDeSolicitud obtiene (o genera) el identificador de la solicitud,
+// lo escribe en la cabecera de la respuesta y devuelve la solicitud con el
+// identificador en su contexto.
+func (o *enrutador) asignarIDDeSolicitud(w http.ResponseWriter, r *http.Request) *http.Request {
+	id := r.Header.Get(o.idDeSolicitud.campo)
+	if !esIDDeSolicitudValido(id) {
+		id = generarUUID()
+	}
+	w.Header().Set(o.idDeSolicitud.campo, id)
+
+	return r.WithContext(context.WithValue(r.Context(), claveIDDeSolicitud, id))
+}
+
+// esIDDeSolicitudValido verifica que el identificador recibido no sea vacío,
+// no supere la longitud máxima y posea sólo caracteres ASCII visibles.
+func esIDDeSolicitudValido(id string) bool {
+	if id == "" || len(id) > longitudMaximaIDDeSolicitud {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ObtenerIDDeSolicitud devuelve el identificador de la solicitud. Sólo posee
+// valor cuando se encuentra activo IdentificadorDeSolicitud.
+func ObtenerIDDeSolicitud(r *http.Request) string {
+	id, _ := r.Context().Value(claveIDDeSolicitud).(string)
+	return id
+}
@@ -0,0 +1,141 @@
+package apirest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestIdentificadorDeSolicitud(t *testing.T) {
+	var idDelContexto string
+	r := CrearEnrutador().IdentificadorDeSolicitud("")
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		idDelContexto = ObtenerIDDeSolicitud(r)
+		return nil, nil
+	})
+
+	pruebas := []struct {
+		nombre   string
+		recibido string
+		generado bool
+	}{
+		{"recibido", "abc-123", false},
+		{"sin identificador", "", true},
+		{"con espacios", "abc 123", true},
+		{"con caracteres no ASCII", "señal", true},
+		{"muy largo", strings.Repeat("a", longitudMaximaIDDeSolicitud+1), true},
+		{"longitud máxima", strings.Repeat("a", longitudMaximaIDDeSolicitud), false},
+	}
+	for _, p := range pruebas {
+		t.Run(p.nombre, func(t *testing.T) {
+			w := solicitar(r, "GET", "/personas", map[string]string{"X-Request-ID": p.recibido})
+			id := w.Header().Get("X-Request-ID")
+			if id != idDelContexto || p.generado && (id == p.recibido || len(id) != 36) || !p.generado && id != p.recibido {
+				t.Errorf("se obtuvo %q (contexto %q)", id, idDelContexto)
+			}
+		})
+	}
+}
+
+func TestIdentificadorDeSolicitudConOtroCampo(t *testing.T) {
+	r := CrearEnrutador().IdentificadorDeSolicitud("x-correlation-id")
+	r.GET("/personas", nil)
+
+	w := solicitar(r, "GET", "/personas", map[string]string{"X-Correlation-Id": "abc"})
+	if w.Header().Get("X-Correlation-Id") != "abc" || w.Header().Get("X-Request-ID") != "" {
+		t.Errorf("se obtuvo la cabecera %v", w.Header())
+	}
+}
+
+func TestIdentificadorDeSolicitudInactivo(t *testing.T) {
+	r := CrearEnrutador()
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, ErrorNuevoNoEncontrado("No existe")
+	})
+
+	w := solicitar(r, "GET", "/personas", map[string]string{"X-Request-ID": "abc"})
+	if w.Header().Get("X-Request-ID") != "" || decodificarCuerpoDeError(t, w).Error.UUID != "" {
+		t.Errorf("se obtuvo %v %s", w.Header(), w.Body.String())
+	}
+}
+
+func TestIDDeSolicitudEnLosErrores(t *testing.T) {
+	errConUUID := ErrorNuevoConflicto("Ya existe")
+	errConUUID.uuid = "uuid-propio"
+
+	pruebas := []struct {
+		nombre string
+		err    error
+		estado int
+		uuid   string
+	}{
+		{"errorAPIREST", ErrorNuevoNoEncontrado("No existe"), 404, "abc"},
+		{"errorAPIREST envuelto", fmt.Errorf("buscar: %w", ErrorNuevoNoEncontrado("No existe")), 404, "abc"},
+		{"error con UUID", errConUUID, 409, "uuid-propio"},
+		{"error sin tipo", errors.New("sin conexión"), 500, "abc"},
+	}
+	for _, p := range pruebas {
+		t.Run(p.nombre, func(t *testing.T) {
+			r := CrearEnrutador().IdentificadorDeSolicitud("")
+			r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+				return nil, p.err
+			})
+
+			w := solicitar(r, "GET", "/personas", map[string]string{"X-Request-ID": "abc"})
+			if c := decodificarCuerpoDeError(t, w); w.Code != p.estado || c.Error.UUID != p.uuid {
+				t.Errorf("se obtuvo %d %q, se esperaba %d %q", w.Code, c.Error.UUID, p.estado, p.uuid)
+			}
+		})
+	}
+}
+
+func TestIDDeSolicitudEnErroresCompartidos(t *testing.T) {
+	// el mismo error se responde en solicitudes concurrentes
+	errCompartido := ErrorNuevoNoEncontrado("No existe").AsignarCodigo("personas.inexistente")
+	r := CrearEnrutador().IdentificadorDeSolicitud("")
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, errCompartido
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			w := solicitar(r, "GET", "/personas", map[string]string{"X-Request-ID": id})
+			if uuid := decodificarCuerpoDeError(t, w).Error.UUID; uuid != id {
+				t.Errorf("se obtuvo el UUID %q, se esperaba %q", uuid, id)
+			}
+		}(fmt.Sprintf("solicitud-%d", i))
+	}
+	wg.Wait()
+
+	if errCompartido.ObtenerUUID() != "" {
+		t.Errorf("se modificó el error compartido: %q", errCompartido.ObtenerUUID())
+	}
+}
+
+func TestAsignarUUIDDeSolicitud(t *testing.T) {
+	errCompartido := ErrorNuevoNoEncontrado("No existe")
+
+	r := CrearEnrutador().IdentificadorDeSolicitud("")
+	var errDeLaSolicitud *errorAPIREST
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		errDeLaSolicitud = errCompartido.AsignarUUIDDeSolicitud(r)
+		return nil, nil
+	})
+	solicitar(r, "GET", "/personas", map[string]string{"X-Request-ID": "abc"})
+
+	if errDeLaSolicitud.ObtenerUUID() != "abc" || errCompartido.ObtenerUUID() != "" || errDeLaSolicitud.Error() != "No existe" {
+		t.Errorf("se obtuvo %q, el error compartido posee %q", errDeLaSolicitud.ObtenerUUID(), errCompartido.ObtenerUUID())
+	}
+
+	// sin identificador de solicitud se genera un UUID
+	if err := errCompartido.AsignarUUIDDeSolicitud(httptest.NewRequest("GET", "/", nil)); len(err.ObtenerUUID()) != 36 {
+		t.Errorf("se obtuvo %q", err.ObtenerUUID())
+	}
+}
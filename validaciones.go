@@ -0,0 +1,117 @@
+package apirest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// errorDeCampo es el error de validación de un campo de la entidad recibida.
+// Los campos del cuerpo se identifican con un puntero JSON (RFC 6901), lo que
+// permite señalar campos anidados ("/direccion/calle") o elementos de listas
+// ("/telefonos/0") (ver PunteroJSON); los parámetros de consulta se
+// identifican con su nombre ("pagina").
+type errorDeCampo struct {
+	Campo      string                 `json:"campo"`                // puntero JSON del campo o nombre del parámetro de consulta
+	Codigo     string                 `json:"codigo"`               // código del error de validación ("requerido", "formato", ...)
+	Mensaje    string                 `json:"mensaje"`              // mensaje de error para ser leído por el usuario
+	Parametros map[string]interface{} `json:"parametros,omitempty"` // valores de la validación ("minimo": 3, ...) (es opcional)
+}
+
+// codigoDeValidacion es el código de los errores creados por
+// ErrorNuevoValidacion.
+const codigoDeValidacion = "apirest.validacion"
+
+// AgregarErrorDeCampo agrega un error de validación de un campo al error
+// actual. El campo se indica con un puntero JSON (ver PunteroJSON) y los
+// parámetros son opcionales (puede recibirse nil).
+// Ejemplo:
+// 	err.AgregarErrorDeCampo("/nombre", "longitudMinima", "El nombre debe poseer al menos 3 caracteres",
+// 		map[string]interface{}{"minimo": 3})
+func (o *errorAPIREST) AgregarErrorDeCampo(campo, codigo, mensaje string, parametros map[string]interface{}) *errorAPIREST {
+	o.erroresDeCampos = append(o.erroresDeCampos, errorDeCampo{
+		Campo:      campo,
+		Codigo:     codigo,
+		Mensaje:    mensaje,
+		Parametros: parametros,
+	})
+	return o
+}
+
+// UnirErroresDeCampos agrega al error actual los errores de campos de los
+// errores recibidos (los errores nil o sin errores de campos se ignoran).
+func (o *errorAPIREST) UnirErroresDeCampos(errs ...error) *errorAPIREST {
+	for _, err := range errs {
+		if errValidacion, ok := ErrorEsValidacion(err); ok && errValidacion != o {
+			o.erroresDeCampos = append(o.erroresDeCampos, errValidacion.erroresDeCampos...)
+		}
+	}
+	return o
+}
+
+// ObtenerErroresDeCampos devuelve los errores de validación de los campos.
+func (o *errorAPIREST) ObtenerErroresDeCampos() []errorDeCampo {
+	return o.erroresDeCampos
+}
+
+// -----------------------------------------------------------------------------
+// Error de validación
+
+// ErrorNuevoValidacion crea un error de tipo:
+// 400 (Mal requerimiento), con el código "apirest.validacion", al que se
+// agregan los errores de los campos con AgregarErrorDeCampo.
+// Ejemplo:
+// 	return nil, apirest.ErrorNuevoValidacion("Los datos de la persona no son válidos").
+// 		AgregarErrorDeCampo("/email", "formato", "El email no es válido", nil)
+func ErrorNuevoValidacion(formato string, args ...interface{}) *errorAPIREST {
+	err := errorNuevo(HTTPEstadoErrorMalRequerimiento, formato, args...)
+	err.codigo = codigoDeValidacion
+
+	return err
+}
+
+// ErrorEsValidacion busca en la cadena de errores el primer error de tipo
+// errorAPIREST que posea errores de campos.
+func ErrorEsValidacion(err error) (*errorAPIREST, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		errAPIREST, ok := err.(*errorAPIREST)
+		if ok && len(errAPIREST.erroresDeCampos) > 0 {
+			return errAPIREST, true
+		}
+	}
+
+	return nil, false
+}
+
+// ErrorUnirValidaciones une los errores de campos de los errores recibidos
+// (por ejemplo, los errores devueltos por distintos validadores) en un único
+// error de validación. Devuelve nil si ninguno de los errores recibidos posee
+// errores de campos.
+// Ejemplo:
+// 	if err := apirest.ErrorUnirValidaciones(validarNombre(p), validarEmail(p)); err != nil {
+// 		return nil, err
+// 	}
+func ErrorUnirValidaciones(errs ...error) error {
+	err := ErrorNuevoValidacion("Los datos recibidos no son válidos")
+	err.asignarRastro(1)
+	err.UnirErroresDeCampos(errs...)
+	if len(err.erroresDeCampos) == 0 {
+		return nil
+	}
+
+	return err
+}
+
+// PunteroJSON devuelve el puntero JSON (RFC 6901) formado por las partes
+// recibidas, escapando los caracteres "~" y "/".
+// Ejemplo:
+// 	apirest.PunteroJSON("telefonos", 0, "numero") // "/telefonos/0/numero"
+func PunteroJSON(partes ...interface{}) string {
+	var escapar = strings.NewReplacer("~", "~0", "/", "~1")
+	var b strings.Builder
+	for _, parte := range partes {
+		b.WriteString("/" + escapar.Replace(fmt.Sprint(parte)))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package apirest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAgregarErrorDeCampo(t *testing.T) {
+	err := ErrorNuevoValidacion("Los datos de la persona no son válidos").
+		AgregarErrorDeCampo("/nombre", "longitudMinima", "El nombre es muy corto", map[string]interface{}{"minimo": 3}).
+		AgregarErrorDeCampo("/email", "formato", "El email no es válido", nil)
+
+	if err.ObtenerHTTPEstado() != HTTPEstadoErrorMalRequerimiento || err.ObtenerCodigo() != "apirest.validacion" {
+		t.Errorf("se obtuvo %v %q", err.ObtenerHTTPEstado(), err.ObtenerCodigo())
+	}
+	esperados := []errorDeCampo{
+		{"/nombre", "longitudMinima", "El nombre es muy corto", map[string]interface{}{"minimo": 3}},
+		{"/email", "formato", "El email no es válido", nil},
+	}
+	if !reflect.DeepEqual(err.ObtenerErroresDeCampos(), esperados) {
+		t.Errorf("se obtuvo %+v, se esperaba %+v", err.ObtenerErroresDeCampos(), esperados)
+	}
+}
+
+func TestErrorEsValidacion(t *testing.T) {
+	errValidacion := ErrorNuevoValidacion("Datos inválidos").AgregarErrorDeCampo("/email", "formato", "El email no es válido", nil)
+
+	if e, ok := ErrorEsValidacion(fmt.Errorf("crear: %w", errValidacion)); !ok || e != errValidacion {
+		t.Errorf("no se encontró el error de validación envuelto")
+	}
+	// un error de validación sin errores de campos no se considera
+	if _, ok := ErrorEsValidacion(ErrorNuevoValidacion("Datos inválidos")); ok {
+		t.Errorf("se encontró un error de validación sin errores de campos")
+	}
+}
+
+func TestUnirErroresDeCampos(t *testing.T) {
+	errNombre := ErrorNuevoValidacion("").AgregarErrorDeCampo("/nombre", "requerido", "El nombre es requerido", nil)
+	errEmail := ErrorNuevoValidacion("").AgregarErrorDeCampo("/email", "formato", "El email no es válido", nil)
+
+	err := ErrorNuevoValidacion("Datos inválidos").AgregarErrorDeCampo("/edad", "minimo", "La edad es menor a 18", nil)
+	err.UnirErroresDeCampos(nil, errNombre, errors.New("otro"), fmt.Errorf("email: %w", errEmail), err)
+
+	var campos []string
+	for _, errCampo := range err.ObtenerErroresDeCampos() {
+		campos = append(campos, errCampo.Campo)
+	}
+	if !reflect.DeepEqual(campos, []string{"/edad", "/nombre", "/email"}) {
+		t.Errorf("se obtuvo %v", campos)
+	}
+}
+
+func TestErrorUnirValidaciones(t *testing.T) {
+	if err := ErrorUnirValidaciones(nil, errors.New("otro"), ErrorNuevoValidacion("")); err != nil {
+		t.Errorf("se esperaba nil, se obtuvo %v", err)
+	}
+
+	err := ErrorUnirValidaciones(
+		ErrorNuevoValidacion("").AgregarErrorDeCampo("/nombre", "requerido", "El nombre es requerido", nil),
+		nil,
+		ErrorNuevoValidacion("").AgregarErrorDeCampo("/email", "formato", "El email no es válido", nil),
+	)
+	errValidacion, ok := ErrorEsValidacion(err)
+	if !ok || len(errValidacion.ObtenerErroresDeCampos()) != 2 || errValidacion.ObtenerCodigo() != "apirest.validacion" {
+		t.Fatalf("se obtuvo %v", err)
+	}
+	if errValidacion.ObtenerRastro().Archivo != "validaciones_test" {
+		t.Errorf("el rastro apunta a %+v", errValidacion.ObtenerRastro())
+	}
+}
+
+func TestPunteroJSON(t *testing.T) {
+	pruebas := []struct {
+		partes   []interface{}
+		esperado string
+	}{
+		{nil, ""},
+		{[]interface{}{"nombre"}, "/nombre"},
+		{[]interface{}{"telefonos", 0, "numero"}, "/telefonos/0/numero"},
+		{[]interface{}{"a/b", "m~n"}, "/a~1b/m~0n"},
+		{[]interface{}{""}, "/"},
+	}
+	for _, p := range pruebas {
+		if puntero := PunteroJSON(p.partes...); puntero != p.esperado {
+			t.Errorf("%v: se obtuvo %q, se esperaba %q", p.partes, puntero, p.esperado)
+		}
+	}
+}
+
+func TestCamposEnElCuerpoDeError(t *testing.T) {
+	r := CrearEnrutador()
+	r.POST("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, ErrorNuevoValidacion("Los datos de la persona no son válidos").
+			AgregarErrorDeCampo("/nombre", "longitudMinima", "El nombre es muy corto", map[string]interface{}{"minimo": 3})
+	})
+	r.GET("/personas", func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		return nil, ErrorNuevoNoEncontrado("No existe")
+	})
+
+	w := solicitar(r, "POST", "/personas", nil)
+	c := decodificarCuerpoDeError(t, w)
+	esperados := []errorDeCampo{{"/nombre", "longitudMinima", "El nombre es muy corto", map[string]interface{}{"minimo": float64(3)}}}
+	if w.Code != http.StatusBadRequest || c.Error.Codigo != "apirest.validacion" || !reflect.DeepEqual(c.Error.Campos, esperados) {
+		t.Errorf("se obtuvo %d %s", w.Code, w.Body.String())
+	}
+
+	// los errores sin errores de campos no incluyen la lista
+	if w := solicitar(r, "GET", "/personas", nil); strings.Contains(w.Body.String(), `"campos"`) {
+		t.Errorf("se obtuvo %s", w.Body.String())
+	}
+}